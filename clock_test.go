@@ -0,0 +1,61 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Unix(59, 0))
+	mustEqual(t, clock.Now(), time.Unix(59, 0))
+
+	clock.Advance(time.Second)
+	mustEqual(t, clock.Now(), time.Unix(60, 0))
+
+	clock.Set(time.Unix(1111111109, 0))
+	mustEqual(t, clock.Now(), time.Unix(1111111109, 0))
+}
+
+func TestTOTPClock(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1111111109, 0))
+
+	totp, err := NewTOTP(TOTPConfig{
+		Algo:   AlgorithmSHA1,
+		Digits: 8,
+		Issuer: "cristalhq",
+		Period: 30,
+		Skew:   1,
+		Clock:  clock,
+	})
+	mustOk(t, err)
+
+	code, err := totp.Code(secretSha1)
+	mustOk(t, err)
+	mustEqual(t, code, "07081804")
+	mustOk(t, totp.Verify("07081804", secretSha1))
+
+	step, err := totp.StepNow()
+	mustOk(t, err)
+	mustEqual(t, step, uint64(37037036))
+
+	next, err := totp.NextRolloverNow()
+	mustOk(t, err)
+	mustEqual(t, next, time.Unix(1111111110, 0))
+
+	remaining, err := totp.RemainingNow()
+	mustOk(t, err)
+	mustEqual(t, remaining, time.Second)
+
+	// Next period starts in 1 second.
+	clock.Advance(time.Second)
+	code, err = totp.Code(secretSha1)
+	mustOk(t, err)
+	mustEqual(t, code, "14050471")
+
+	// Previous code is accepted within the skew.
+	mustOk(t, totp.Verify("07081804", secretSha1))
+
+	clock.Advance(30 * time.Second)
+	mustOk(t, totp.Verify("14050471", secretSha1))
+	mustEqual(t, totp.Verify("07081804", secretSha1), ErrCodeIsNotValid)
+}
@@ -0,0 +1,231 @@
+// Command otp generates and validates HOTP and TOTP codes like oathtool.
+//
+// Usage:
+//
+//	otp [flags] KEY [OTP]
+//	otp -url ACCOUNT -issuer ISSUER [flags] KEY
+//	otp -inspect URL
+//
+// KEY is a hex secret or a base32 secret with -base32.
+// Without OTP prints the code (or window+1 codes), with OTP validates it
+// and prints the matched position in the window.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cristalhq/otp"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type config struct {
+	totp    bool
+	base32  bool
+	algo    string
+	digits  uint
+	counter uint64
+	step    uint64
+	window  uint
+	now     string
+	issuer  string
+	url     string
+	inspect string
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	var cfg config
+
+	fset := flag.NewFlagSet("otp", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	fset.BoolVar(&cfg.totp, "totp", false, "use TOTP instead of HOTP")
+	fset.BoolVar(&cfg.base32, "base32", false, "KEY is base32 encoded instead of hex")
+	fset.StringVar(&cfg.algo, "algo", "SHA1", "hash algorithm: SHA1, SHA256 or SHA512")
+	fset.UintVar(&cfg.digits, "digits", 6, "number of digits in the code")
+	fset.Uint64Var(&cfg.counter, "counter", 0, "HOTP counter")
+	fset.Uint64Var(&cfg.step, "step", 30, "TOTP time step in seconds")
+	fset.UintVar(&cfg.window, "window", 0, "window of counters or time steps")
+	fset.StringVar(&cfg.now, "now", "", "TOTP time as RFC 3339 or Unix seconds (default current time)")
+	fset.StringVar(&cfg.issuer, "issuer", "otp", "issuer for generated URLs")
+	fset.StringVar(&cfg.url, "url", "", "print otpauth URL for the given account")
+	fset.StringVar(&cfg.inspect, "inspect", "", "parse and print the given otpauth URL")
+
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+	switch {
+	case cfg.inspect != "":
+		err = inspect(stdout, cfg)
+	case fset.NArg() == 1 || fset.NArg() == 2:
+		err = generate(stdout, cfg, fset.Args())
+	default:
+		fset.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "otp: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func generate(w io.Writer, cfg config, args []string) error {
+	secret, err := parseSecret(args[0], cfg.base32)
+	if err != nil {
+		return err
+	}
+	secretB32 := secret.Base32()
+
+	var algo otp.Algorithm
+	if err := algo.UnmarshalText([]byte(cfg.algo)); err != nil {
+		return err
+	}
+
+	if !cfg.totp {
+		hotp, err := otp.NewHOTP(otp.HOTPConfig{
+			Algo:      algo,
+			Digits:    cfg.digits,
+			Issuer:    cfg.issuer,
+			LookAhead: cfg.window,
+		})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case cfg.url != "":
+			fmt.Fprintln(w, hotp.GenerateURLWithCounter(cfg.url, cfg.counter, secret.Bytes()))
+		case len(args) == 2:
+			counter, err := hotp.ValidateCounter(args[1], cfg.counter, secretB32)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, counter-cfg.counter)
+		default:
+			for i := uint64(0); i <= uint64(cfg.window); i++ {
+				code, err := hotp.GenerateCode(cfg.counter+i, secretB32)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(w, code)
+			}
+		}
+		return nil
+	}
+
+	now, err := parseNow(cfg.now)
+	if err != nil {
+		return err
+	}
+
+	totp, err := otp.NewTOTP(otp.TOTPConfig{
+		Algo:   algo,
+		Digits: cfg.digits,
+		Issuer: cfg.issuer,
+		Period: cfg.step,
+		Skew:   cfg.window,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.url != "":
+		fmt.Fprintln(w, totp.GenerateURL(cfg.url, secret.Bytes()))
+	case len(args) == 2:
+		res, err := totp.ValidateResult(args[1], now, secretB32)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, res.Offset)
+	default:
+		step := time.Duration(cfg.step) * time.Second
+		for i := 0; i <= int(cfg.window); i++ {
+			code, err := totp.GenerateCode(secretB32, now.Add(time.Duration(i)*step))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, code)
+		}
+	}
+	return nil
+}
+
+func inspect(w io.Writer, cfg config) error {
+	key, err := otp.ParseKeyFromURL(cfg.inspect)
+	if err != nil {
+		return err
+	}
+
+	// Algorithm and digits are printed with the defaults applied.
+	var o otp.OTP
+	if key.Type() == "hotp" {
+		o, err = otp.NewHOTPFromKey(key)
+	} else {
+		o, err = otp.NewTOTPFromKey(key)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Type:      %s\n", key.Type())
+	fmt.Fprintf(w, "Issuer:    %s\n", key.Issuer())
+	fmt.Fprintf(w, "Account:   %s\n", key.Account())
+	fmt.Fprintf(w, "Secret:    %s\n", key.Secret())
+	fmt.Fprintf(w, "Algorithm: %s\n", o.Algorithm())
+	fmt.Fprintf(w, "Digits:    %d\n", o.Digits())
+	if key.Type() == "hotp" {
+		fmt.Fprintf(w, "Counter:   %d\n", key.Counter())
+	} else {
+		fmt.Fprintf(w, "Period:    %d\n", key.Period())
+	}
+
+	now, err := parseNow(cfg.now)
+	if err != nil {
+		return err
+	}
+	code, err := key.GenerateCode(now)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Code:      %s\n", code)
+	return nil
+}
+
+func parseSecret(s string, isBase32 bool) (*otp.Secret, error) {
+	enc := otp.SecretHex
+	if isBase32 {
+		enc = otp.SecretBase32
+	}
+
+	secret, err := otp.NewSecretFromString(s, enc)
+	if err != nil {
+		return nil, fmt.Errorf("KEY is not a valid %s: %w", enc, err)
+	}
+	return secret, nil
+}
+
+func parseNow(s string) (time.Time, error) {
+	if s == "" {
+		return time.Now(), nil
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New("-now must be RFC 3339 or Unix seconds")
+	}
+	return t, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	const (
+		keyHex    = "3132333435363738393031323334353637383930"
+		keyBase32 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	)
+
+	testCases := []struct {
+		args []string
+		want string
+		code int
+	}{
+		{[]string{keyHex}, "755224\n", 0},
+		{[]string{"-counter", "3", keyHex}, "969429\n", 0},
+		{[]string{"-base32", "-counter", "1", "-window", "2", keyBase32}, "287082\n359152\n969429\n", 0},
+		{[]string{"-base32", "-counter", "1", "-window", "5", keyBase32, "338314"}, "3\n", 0},
+		{[]string{"-counter", "1", "-window", "2", keyHex, "338314"}, "", 1},
+		{[]string{"-totp", "-digits", "8", "-now", "59", keyHex}, "94287082\n", 0},
+		{[]string{"-totp", "-digits", "8", "-now", "2009-02-13T23:31:30Z", keyHex}, "89005924\n", 0},
+		{[]string{"-totp", "-digits", "8", "-window", "1", "-now", "1111111141", keyHex, "14050471"}, "-1\n", 0},
+		{[]string{"-totp", "-digits", "8", "-now", "1111111141", keyHex, "14050471"}, "", 1},
+		{[]string{"-totp", "-algo", "SHA256", "-digits", "8", "-now", "59", "3132333435363738393031323334353637383930313233343536373839303132"}, "46119246\n", 0},
+		{[]string{"-totp", "-digits", "8", "-issuer", "cristalhq", "-url", "alice@bob.com", keyHex}, "otpauth://totp/cristalhq:alice@bob.com?algorithm=SHA1&digits=8&issuer=cristalhq&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\n", 0},
+		{[]string{"-counter", "5", "-issuer", "cristalhq", "-url", "alice@bob.com", keyHex}, "otpauth://hotp/cristalhq:alice@bob.com?algorithm=SHA1&counter=5&digits=6&issuer=cristalhq&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\n", 0},
+		{[]string{"-inspect", "otpauth://hotp/cristalhq:alice@bob.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=2&digits=6"}, "Type:      hotp\nIssuer:    cristalhq\nAccount:   alice@bob.com\nSecret:    GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\nAlgorithm: SHA1\nDigits:    6\nCounter:   2\nCode:      359152\n", 0},
+		{[]string{"-inspect", "otpauth://hotp/alice@bob.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=2"}, "Type:      hotp\nIssuer:    \nAccount:   alice@bob.com\nSecret:    GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\nAlgorithm: SHA1\nDigits:    6\nCounter:   2\nCode:      359152\n", 0},
+		{[]string{"not-hex"}, "", 1},
+		{[]string{"-algo", "MD5", keyHex}, "", 1},
+		{[]string{}, "", 2},
+	}
+
+	for _, tc := range testCases {
+		var stdout, stderr bytes.Buffer
+		code := run(tc.args, &stdout, &stderr)
+		if code != tc.code || stdout.String() != tc.want {
+			t.Fatalf("%v:\nhave: %d %q\nwant: %d %q\nstderr: %s", tc.args, code, stdout.String(), tc.code, tc.want, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package otp
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Encoder encodes a truncated HMAC value into a code.
+type Encoder interface {
+	// Encode writes the code for the value into dst,
+	// len(dst) is the code length.
+	Encode(dst []byte, value uint64)
+}
+
+var (
+	// EncoderDecimal produces decimal codes as defined by HOTP and TOTP.
+	EncoderDecimal Encoder = AlphabetEncoder("0123456789")
+	// EncoderHex produces uppercase hexadecimal codes.
+	EncoderHex Encoder = AlphabetEncoder("0123456789ABCDEF")
+	// EncoderCrockford produces Crockford's base32 codes.
+	EncoderCrockford Encoder = AlphabetEncoder("0123456789ABCDEFGHJKMNPQRSTVWXYZ")
+	// EncoderLetters produces uppercase letters only codes.
+	EncoderLetters Encoder = AlphabetEncoder("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	// EncoderSteam produces Steam Guard codes.
+	EncoderSteam Encoder = steamEncoder{}
+)
+
+// AlphabetEncoder encodes the value in positional notation over the alphabet,
+// most significant symbol first. The alphabet must have at least 2 symbols,
+// all unique and ASCII.
+type AlphabetEncoder string
+
+// Encode implements Encoder.
+func (a AlphabetEncoder) Encode(dst []byte, value uint64) {
+	base := uint64(len(a))
+	for i := len(dst) - 1; i >= 0; i-- {
+		dst[i] = a[value%base]
+		value /= base
+	}
+}
+
+// valid reports whether the alphabet has at least 2 unique ASCII symbols.
+func (a AlphabetEncoder) valid() bool {
+	if len(a) < 2 {
+		return false
+	}
+	var seen [utf8.RuneSelf]bool
+	for i := 0; i < len(a); i++ {
+		c := a[i]
+		if c >= utf8.RuneSelf || seen[c] {
+			return false
+		}
+		seen[c] = true
+	}
+	return true
+}
+
+// steamAlphabet is used by Steam Guard instead of decimal digits.
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// steamEncoder is like AlphabetEncoder but least significant symbol first.
+type steamEncoder struct{}
+
+func (steamEncoder) Encode(dst []byte, value uint64) {
+	base := uint64(len(steamAlphabet))
+	for i := range dst {
+		dst[i] = steamAlphabet[value%base]
+		value /= base
+	}
+}
+
+// alphabetPrefix is the name prefix of the custom AlphabetEncoder.
+const alphabetPrefix = "alphabet:"
+
+var encoderNames = []struct {
+	name string
+	enc  Encoder
+}{
+	{"decimal", EncoderDecimal},
+	{"hex", EncoderHex},
+	{"crockford", EncoderCrockford},
+	{"letters", EncoderLetters},
+	{"steam", EncoderSteam},
+}
+
+// EncoderName returns the name of the predefined encoder like "decimal"
+// or "steam", "alphabet:" followed by the symbols for other AlphabetEncoder
+// and empty name for nil. Returns ErrEncoderNotValid for other encoders.
+func EncoderName(enc Encoder) (string, error) {
+	if enc == nil {
+		return "", nil
+	}
+	for _, e := range encoderNames {
+		if e.enc == enc {
+			return e.name, nil
+		}
+	}
+	if a, ok := enc.(AlphabetEncoder); ok && a.valid() {
+		return alphabetPrefix + string(a), nil
+	}
+	return "", ErrEncoderNotValid
+}
+
+// EncoderByName returns the encoder for the name returned by EncoderName.
+func EncoderByName(name string) (Encoder, error) {
+	if name == "" {
+		return nil, nil
+	}
+	for _, e := range encoderNames {
+		if e.name == name {
+			return e.enc, nil
+		}
+	}
+	if strings.HasPrefix(name, alphabetPrefix) {
+		if a := AlphabetEncoder(name[len(alphabetPrefix):]); a.valid() {
+			return a, nil
+		}
+	}
+	return nil, ErrEncoderNotValid
+}
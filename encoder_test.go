@@ -0,0 +1,108 @@
+package otp
+
+import (
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	testCases := []struct {
+		encoder Encoder
+		codes   [3]string
+	}{
+		{nil, [3]string{"755224", "287082", "359152"}},
+		{EncoderDecimal, [3]string{"755224", "287082", "359152"}},
+		{EncoderHex, [3]string{"93CF18", "397EEA", "2FEF30"}},
+		{EncoderCrockford, [3]string{"697KRR", "0KJZQA", "42ZVSG"}},
+		{EncoderLetters, [3]string{"EDLDMM", "OCQHWS", "LOPEAI"}},
+		{EncoderSteam, [3]string{"GG5F56", "PV9M4J", "B26KJF"}},
+	}
+
+	for _, tc := range testCases {
+		hotp, err := NewHOTP(HOTPConfig{
+			Algo:    AlgorithmSHA1,
+			Digits:  6,
+			Issuer:  "cristalhq",
+			Encoder: tc.encoder,
+		})
+		mustOk(t, err)
+
+		for counter, want := range tc.codes {
+			code, err := hotp.GenerateCode(uint64(counter), secretSha1)
+			mustOk(t, err)
+			mustEqual(t, code, want)
+
+			err = hotp.Validate(want, uint64(counter), secretSha1)
+			mustOk(t, err)
+		}
+
+		err = hotp.Validate(tc.codes[0][:5], 0, secretSha1)
+		mustEqual(t, err, ErrCodeLengthMismatch)
+
+		err = hotp.Validate(tc.codes[1], 0, secretSha1)
+		mustEqual(t, err, ErrCodeIsNotValid)
+	}
+}
+
+func TestAlphabetEncoder(t *testing.T) {
+	enc := AlphabetEncoder("01")
+
+	code := make([]byte, 8)
+	enc.Encode(code, 0b1010_0110)
+	mustEqual(t, string(code), "10100110")
+
+	code = make([]byte, 4)
+	enc.Encode(code, 0b1010_0110)
+	mustEqual(t, string(code), "0110")
+}
+
+func TestEncoderDigits(t *testing.T) {
+	testCases := []struct {
+		enc    Encoder
+		digits uint
+		long   bool
+		err    error
+	}{
+		{nil, 9, false, nil},
+		{nil, 10, false, ErrDigitsNotValid},
+		{nil, 12, true, nil},
+		{nil, 13, true, ErrDigitsNotValid},
+		{EncoderHex, 7, false, nil},
+		{EncoderHex, 8, false, ErrDigitsNotValid},
+		{EncoderHex, 12, false, ErrDigitsNotValid},
+		{EncoderHex, 15, true, nil},
+		{EncoderHex, 16, true, ErrDigitsNotValid},
+		{EncoderCrockford, 6, false, nil},
+		{EncoderCrockford, 7, false, ErrDigitsNotValid},
+		{EncoderSteam, 6, false, nil},
+		{EncoderSteam, 7, false, ErrDigitsNotValid},
+		{EncoderSteam, 13, true, nil},
+		{EncoderSteam, 14, true, ErrDigitsNotValid},
+		{AlphabetEncoder("01"), 31, false, nil},
+		{AlphabetEncoder("01"), 32, false, ErrDigitsNotValid},
+		{AlphabetEncoder(""), 6, false, ErrEncoderNotValid},
+		{AlphabetEncoder("0"), 6, false, ErrEncoderNotValid},
+		{AlphabetEncoder("αβγ"), 6, false, ErrEncoderNotValid},
+		{AlphabetEncoder("0011"), 6, false, ErrEncoderNotValid},
+	}
+
+	for _, tc := range testCases {
+		err := HOTPConfig{
+			Algo:      AlgorithmSHA1,
+			Digits:    tc.digits,
+			Issuer:    "cristalhq",
+			Encoder:   tc.enc,
+			LongCodes: tc.long,
+		}.Validate()
+		mustEqual(t, err, tc.err)
+
+		err = TOTPConfig{
+			Algo:      AlgorithmSHA1,
+			Digits:    tc.digits,
+			Issuer:    "cristalhq",
+			Period:    30,
+			Encoder:   tc.enc,
+			LongCodes: tc.long,
+		}.Validate()
+		mustEqual(t, err, tc.err)
+	}
+}
@@ -9,9 +9,10 @@ import (
 
 func ExampleHOTP() {
 	hotp, err := otp.NewHOTP(otp.HOTPConfig{
-		Algo:   otp.AlgorithmSHA1,
-		Digits: 10,
-		Issuer: "cristalhq",
+		Algo:      otp.AlgorithmSHA1,
+		Digits:    10,
+		Issuer:    "cristalhq",
+		LongCodes: true,
 	})
 	checkErr(err)
 
@@ -25,16 +26,17 @@ func ExampleHOTP() {
 	checkErr(err)
 
 	// Output:
-	// 0979090604
+	// 1706466408
 }
 
 func ExampleTOTP() {
 	totp, err := otp.NewTOTP(otp.TOTPConfig{
-		Algo:   otp.AlgorithmSHA1,
-		Digits: 10,
-		Issuer: "cristalhq",
-		Period: 30,
-		Skew:   2,
+		Algo:      otp.AlgorithmSHA1,
+		Digits:    10,
+		Issuer:    "cristalhq",
+		Period:    30,
+		Skew:      2,
+		LongCodes: true,
 	})
 	checkErr(err)
 
@@ -50,7 +52,7 @@ func ExampleTOTP() {
 	checkErr(err)
 
 	// Output:
-	// 0462778229
+	// 3272184247
 }
 
 func checkErr(err error) {
@@ -0,0 +1,108 @@
+package otp
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrFactorNotValid = errors.New("moving factor is not valid")
+
+// OTP is implemented by HOTP and TOTP so they can be used interchangeably.
+type OTP interface {
+	// GenerateFactor generates the code for the moving factor and secret.
+	GenerateFactor(secret string, factor Factor) (string, error)
+	// ValidateFactor validates the passcode for the moving factor and secret.
+	ValidateFactor(passcode, secret string, factor Factor) error
+	// GenerateURL for the account for a given secret.
+	GenerateURL(account string, secret []byte) string
+
+	Algorithm() Algorithm
+	Digits() uint
+	Issuer() string
+}
+
+var (
+	_ OTP = (*HOTP)(nil)
+	_ OTP = (*TOTP)(nil)
+)
+
+// Factor is the moving factor of OTP: a counter or a time.
+// See: https://datatracker.ietf.org/doc/html/rfc4226#section-5.2
+type Factor struct {
+	counter uint64
+	at      time.Time
+	isTime  bool
+}
+
+// CounterFactor returns the counter moving factor.
+// For TOTP the counter is the time step.
+func CounterFactor(counter uint64) Factor {
+	return Factor{counter: counter}
+}
+
+// TimeFactor returns the time moving factor, it is valid only for TOTP.
+func TimeFactor(at time.Time) Factor {
+	return Factor{at: at, isTime: true}
+}
+
+// Counter returns the counter and reports whether it is a counter factor.
+func (f Factor) Counter() (uint64, bool) { return f.counter, !f.isTime }
+
+// Time returns the time and reports whether it is a time factor.
+func (f Factor) Time() (time.Time, bool) { return f.at, f.isTime }
+
+// GenerateFactor implements OTP, only the counter factor is valid.
+func (h *HOTP) GenerateFactor(secret string, factor Factor) (string, error) {
+	counter, ok := factor.Counter()
+	if !ok {
+		return "", ErrFactorNotValid
+	}
+	return h.GenerateCode(counter, secret)
+}
+
+// ValidateFactor implements OTP, only the counter factor is valid.
+// Unlike ValidateCounter the look-ahead window is not used.
+func (h *HOTP) ValidateFactor(passcode, secret string, factor Factor) error {
+	counter, ok := factor.Counter()
+	if !ok {
+		return ErrFactorNotValid
+	}
+	return h.Validate(passcode, counter, secret)
+}
+
+// Algorithm returns the algorithm.
+func (h *HOTP) Algorithm() Algorithm { return h.cfg.Algo }
+
+// Digits returns the code length.
+func (h *HOTP) Digits() uint { return h.cfg.Digits }
+
+// Issuer returns the issuer.
+func (h *HOTP) Issuer() string { return h.cfg.Issuer }
+
+// GenerateFactor implements OTP.
+func (t *TOTP) GenerateFactor(secret string, factor Factor) (string, error) {
+	if at, ok := factor.Time(); ok {
+		return t.GenerateCode(secret, at)
+	}
+	counter, _ := factor.Counter()
+	return t.hotp.GenerateCode(counter, secret)
+}
+
+// ValidateFactor implements OTP. The skew window is used for the time factor,
+// the counter factor (time step) is checked without it.
+func (t *TOTP) ValidateFactor(passcode, secret string, factor Factor) error {
+	if at, ok := factor.Time(); ok {
+		return t.Validate(passcode, at, secret)
+	}
+	counter, _ := factor.Counter()
+	return t.hotp.Validate(passcode, counter, secret)
+}
+
+// Algorithm returns the algorithm.
+func (t *TOTP) Algorithm() Algorithm { return t.cfg.Algo }
+
+// Digits returns the code length.
+func (t *TOTP) Digits() uint { return t.cfg.Digits }
+
+// Issuer returns the issuer.
+func (t *TOTP) Issuer() string { return t.cfg.Issuer }
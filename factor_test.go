@@ -0,0 +1,74 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOTP(t *testing.T) {
+	hotp, err := NewHOTP(HOTPConfig{
+		Algo:   AlgorithmSHA1,
+		Digits: 8,
+		Issuer: "cristalhq",
+	})
+	mustOk(t, err)
+
+	totp, err := NewTOTP(TOTPConfig{
+		Algo:   AlgorithmSHA1,
+		Digits: 8,
+		Issuer: "cristalhq",
+		Period: 30,
+		Skew:   1,
+	})
+	mustOk(t, err)
+
+	testCases := []struct {
+		otp    OTP
+		factor Factor
+		code   string
+	}{
+		{hotp, CounterFactor(1), "94287082"},
+		{totp, CounterFactor(1), "94287082"},
+		{totp, TimeFactor(time.Unix(59, 0)), "94287082"},
+		{totp, TimeFactor(time.Unix(1111111109, 0)), "07081804"},
+	}
+
+	for _, tc := range testCases {
+		code, err := tc.otp.GenerateFactor(secretSha1, tc.factor)
+		mustOk(t, err)
+		mustEqual(t, code, tc.code)
+
+		mustOk(t, tc.otp.ValidateFactor(code, secretSha1, tc.factor))
+		mustEqual(t, tc.otp.ValidateFactor("00000000", secretSha1, tc.factor), ErrCodeIsNotValid)
+
+		mustEqual(t, tc.otp.Algorithm(), AlgorithmSHA1)
+		mustEqual(t, tc.otp.Digits(), uint(8))
+		mustEqual(t, tc.otp.Issuer(), "cristalhq")
+	}
+
+	// Skew is used only for the time factor.
+	mustOk(t, totp.ValidateFactor("94287082", secretSha1, TimeFactor(time.Unix(89, 0))))
+	mustEqual(t, totp.ValidateFactor("94287082", secretSha1, CounterFactor(2)), ErrCodeIsNotValid)
+
+	_, err = hotp.GenerateFactor(secretSha1, TimeFactor(time.Unix(59, 0)))
+	mustEqual(t, err, ErrFactorNotValid)
+
+	err = hotp.ValidateFactor("94287082", secretSha1, TimeFactor(time.Unix(59, 0)))
+	mustEqual(t, err, ErrFactorNotValid)
+}
+
+func TestFactor(t *testing.T) {
+	counter, ok := CounterFactor(42).Counter()
+	mustEqual(t, counter, uint64(42))
+	mustEqual(t, ok, true)
+
+	_, ok = CounterFactor(42).Time()
+	mustEqual(t, ok, false)
+
+	at, ok := TimeFactor(time.Unix(42, 0)).Time()
+	mustEqual(t, at, time.Unix(42, 0))
+	mustEqual(t, ok, true)
+
+	_, ok = TimeFactor(time.Unix(42, 0)).Counter()
+	mustEqual(t, ok, false)
+}
@@ -4,9 +4,6 @@ import (
 	"crypto/hmac"
 	"crypto/subtle"
 	"encoding/binary"
-	"fmt"
-	"math"
-	"net/url"
 )
 
 // HOTP represents HOTP codes generator and validator.
@@ -18,16 +15,44 @@ type HOTPConfig struct {
 	Algo   Algorithm
 	Digits uint
 	Issuer string
+
+	// Encoder for the codes of Digits length, EncoderDecimal if nil.
+	// Marshaled to JSON by name, see EncoderByName.
+	Encoder Encoder `json:"-"`
+
+	// LongCodes enables extended truncation with 63 bits of the HMAC
+	// so decimal codes up to 12 digits are uniformly distributed.
+	// Codes are not compatible with standard HOTP apps.
+	LongCodes bool
+
+	// SecretEncoding of the string secrets, base32 by default.
+	SecretEncoding SecretEncoding
+
+	// LookAhead is the number of counters after the expected one
+	// that are checked by ValidateCounter.
+	// See: https://datatracker.ietf.org/doc/html/rfc4226#section-7.4
+	LookAhead uint
 }
 
 func (cfg HOTPConfig) Validate() error {
+	return cfg.validate(true)
+}
+
+// validate checks the config, the issuer is required only when needIssuer.
+func (cfg HOTPConfig) validate(needIssuer bool) error {
 	switch {
 	case cfg.Algo == 0 || cfg.Algo >= algorithmMax:
 		return ErrUnsupportedAlgorithm
 	case cfg.Digits == 0:
 		return ErrNoDigits
-	case cfg.Issuer == "":
+	case !validEncoder(cfg.Encoder):
+		return ErrEncoderNotValid
+	case !validDigits(cfg.Digits, cfg.Encoder, cfg.LongCodes):
+		return ErrDigitsNotValid
+	case needIssuer && cfg.Issuer == "":
 		return ErrEmptyIssuer
+	case cfg.SecretEncoding < 0 || cfg.SecretEncoding >= secretEncodingMax:
+		return ErrEncodingNotValid
 	default:
 		return nil
 	}
@@ -35,54 +60,128 @@ func (cfg HOTPConfig) Validate() error {
 
 // NewHOTP creates new HOTP.
 func NewHOTP(cfg HOTPConfig) (*HOTP, error) {
-	if err := cfg.Validate(); err != nil {
+	return newHOTP(cfg, true)
+}
+
+func newHOTP(cfg HOTPConfig, needIssuer bool) (*HOTP, error) {
+	if err := cfg.validate(needIssuer); err != nil {
 		return nil, err
 	}
 	return &HOTP{cfg: cfg}, nil
 }
 
-// GenerateURL for the account for a given secret.
+// Config returns a copy of the HOTP config.
+func (h *HOTP) Config() HOTPConfig { return h.cfg }
+
+// NewHOTPFromKey creates new HOTP from the HOTP key.
+// Unset algorithm and digits default to SHA1 and 6 digits.
+// The key may have no issuer.
+func NewHOTPFromKey(key *Key) (*HOTP, error) {
+	if key.Type() != "hotp" {
+		return nil, ErrTypeNotValid
+	}
+	algo, digits := key.algoDigits()
+	return newHOTP(HOTPConfig{
+		Algo:   algo,
+		Digits: digits,
+		Issuer: key.Issuer(),
+	}, false)
+}
+
+// GenerateURL for the account for a given secret with counter 0.
+//
+// WARNING: the URL has no sign of LongCodes, apps enrolled from it
+// produce different codes. Use GenerateURLStrict to get an error.
 func (h *HOTP) GenerateURL(account string, secret []byte) string {
-	v := url.Values{}
-	v.Set("algorithm", h.cfg.Algo.String())
-	v.Set("digits", atoi(uint64(h.cfg.Digits)))
-	v.Set("issuer", h.cfg.Issuer)
-	v.Set("secret", b32Enc(secret))
+	return h.GenerateURLWithCounter(account, 0, secret)
+}
 
-	u := url.URL{
-		Scheme:   "otpauth",
-		Host:     "hotp",
-		Path:     "/" + h.cfg.Issuer + ":" + account,
-		RawQuery: v.Encode(),
+// GenerateURLWithCounter for the account for a given secret
+// with the counter the authenticator app starts from.
+func (h *HOTP) GenerateURLWithCounter(account string, counter uint64, secret []byte) string {
+	return h.key(account, counter, secret).String()
+}
+
+// GenerateURLStrict is like GenerateURLWithCounter but returns
+// ErrLongCodesInURL for LongCodes.
+func (h *HOTP) GenerateURLStrict(account string, counter uint64, secret []byte) (string, error) {
+	if h.cfg.LongCodes {
+		return "", ErrLongCodesInURL
 	}
-	return u.String()
+	return h.key(account, counter, secret).String(), nil
+}
+
+func (h *HOTP) key(account string, counter uint64, secret []byte) *Key {
+	return NewKey("hotp", h.cfg.Issuer, account).
+		SetAlgorithm(h.cfg.Algo).
+		SetDigits(h.cfg.Digits).
+		SetCounter(counter).
+		SetSecret(secret)
 }
 
 // GenerateCode for the given counter and secret.
 func (h *HOTP) GenerateCode(counter uint64, secret string) (string, error) {
-	secretBytes, err := b32Dec(secret)
+	st, err := h.state(secret)
 	if err != nil {
-		return "", ErrEncodingNotValid
+		return "", err
 	}
+	return h.generateCode(counter, st), nil
+}
 
-	buf := make([]byte, 8)
-	binary.BigEndian.PutUint64(buf, counter)
+// GenerateCodeSecret for the given counter and decoded secret.
+func (h *HOTP) GenerateCodeSecret(counter uint64, secret *Secret) string {
+	st := secret.state(h.cfg.Algo)
+	code := h.generateCode(counter, st)
+	secret.release(h.cfg.Algo, st)
+	return code
+}
 
-	mac := hmac.New(h.cfg.Algo.Hash, secretBytes)
-	mac.Write(buf)
-	sum := mac.Sum(nil)
+// AppendCode appends the code for the given counter and decoded secret to dst
+// and returns the extended buffer. It does not allocate when dst has capacity
+// for Digits more bytes.
+func (h *HOTP) AppendCode(dst []byte, counter uint64, secret *Secret) []byte {
+	st := secret.state(h.cfg.Algo)
+	dst = h.appendCode(dst, st, counter)
+	secret.release(h.cfg.Algo, st)
+	return dst
+}
 
-	// See: http://tools.ietf.org/html/rfc4226#section-5.4
-	offset := sum[len(sum)-1] & 0xf
-	var value int64
-	value |= int64(sum[offset]&0x7f) << 24
-	value |= int64(sum[offset+1]&0xff) << 16
-	value |= int64(sum[offset+2]&0xff) << 8
-	value |= int64(sum[offset+3] & 0xff)
+// state decodes the string secret with the configured encoding
+// and returns the HMAC state for a single call, it is not pooled.
+func (h *HOTP) state(secret string) (*macState, error) {
+	key, err := decodeSecret(secret, h.cfg.SecretEncoding)
+	if err != nil {
+		return nil, err
+	}
+	return &macState{mac: hmac.New(h.cfg.Algo.Hash, key)}, nil
+}
 
-	length := int64(math.Pow10(int(h.cfg.Digits)))
-	code := fmt.Sprintf(fmt.Sprintf("%%0%dd", h.cfg.Digits), value%length)
-	return code, nil
+func (h *HOTP) generateCode(counter uint64, st *macState) string {
+	return string(h.appendCode(make([]byte, 0, h.cfg.Digits), st, counter))
+}
+
+func (h *HOTP) appendCode(dst []byte, st *macState, counter uint64) []byte {
+	sum := st.sum(counter)
+	value := uint64(truncate(sum))
+	if h.cfg.LongCodes {
+		value = truncateLong(sum)
+	}
+
+	n := len(dst)
+	dst = append(dst, make([]byte, h.cfg.Digits)...)
+	switch enc := h.cfg.Encoder; enc {
+	case nil, EncoderDecimal:
+		encodeDecimal(dst[n:], value)
+	default:
+		enc.Encode(dst[n:], value)
+	}
+	return dst
+}
+
+// match reports whether the passcode is the code for the counter.
+func (h *HOTP) match(st *macState, passcode string, counter uint64) bool {
+	st.code = h.appendCode(st.code[:0], st, counter)
+	return subtle.ConstantTimeCompare(st.code, []byte(passcode)) == 1
 }
 
 // Validate the given passcode, counter and secret.
@@ -91,14 +190,197 @@ func (h *HOTP) Validate(passcode string, counter uint64, secret string) error {
 		return ErrCodeLengthMismatch
 	}
 
-	code, err := h.GenerateCode(counter, secret)
+	st, err := h.state(secret)
 	if err != nil {
 		return err
 	}
+	if !h.match(st, passcode, counter) {
+		return ErrCodeIsNotValid
+	}
+	return nil
+}
 
-	ok := subtle.ConstantTimeCompare([]byte(code), []byte(passcode))
-	if ok != 1 {
+// ValidateSecret validates the given passcode, counter and decoded secret.
+func (h *HOTP) ValidateSecret(passcode string, counter uint64, secret *Secret) error {
+	if len(passcode) != int(h.cfg.Digits) {
+		return ErrCodeLengthMismatch
+	}
+
+	st := secret.state(h.cfg.Algo)
+	ok := h.match(st, passcode, counter)
+	secret.release(h.cfg.Algo, st)
+	if !ok {
 		return ErrCodeIsNotValid
 	}
 	return nil
 }
+
+// ValidateCounter validates the given passcode against counters from counter
+// to counter+LookAhead and returns the matched one.
+// The next expected counter is the matched one plus 1.
+func (h *HOTP) ValidateCounter(passcode string, counter uint64, secret string) (uint64, error) {
+	if len(passcode) != int(h.cfg.Digits) {
+		return 0, ErrCodeLengthMismatch
+	}
+
+	st, err := h.state(secret)
+	if err != nil {
+		return 0, err
+	}
+	return h.validateWindow(passcode, counter, h.cfg.LookAhead, st)
+}
+
+// ValidateCounterSecret is like ValidateCounter but for the decoded secret.
+func (h *HOTP) ValidateCounterSecret(passcode string, counter uint64, secret *Secret) (uint64, error) {
+	st := secret.state(h.cfg.Algo)
+	defer secret.release(h.cfg.Algo, st)
+	return h.validateWindow(passcode, counter, h.cfg.LookAhead, st)
+}
+
+// Resync the counter with 2 consecutive passcodes searching the window
+// of the given size after the counter. Returns the counter of passcode2,
+// the next expected counter is the returned one plus 1.
+// See: https://datatracker.ietf.org/doc/html/rfc4226#section-7.4
+func (h *HOTP) Resync(passcode1, passcode2 string, counter uint64, window uint, secret string) (uint64, error) {
+	if len(passcode1) != int(h.cfg.Digits) || len(passcode2) != int(h.cfg.Digits) {
+		return 0, ErrCodeLengthMismatch
+	}
+
+	st, err := h.state(secret)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := uint64(0); i <= uint64(window); i++ {
+		c := counter + i
+		if c+1 < counter {
+			break
+		}
+
+		if h.match(st, passcode1, c) && h.match(st, passcode2, c+1) {
+			return c + 1, nil
+		}
+	}
+	return 0, ErrCodeIsNotValid
+}
+
+func (h *HOTP) validateWindow(passcode string, counter uint64, window uint, st *macState) (uint64, error) {
+	if len(passcode) != int(h.cfg.Digits) {
+		return 0, ErrCodeLengthMismatch
+	}
+
+	for i := uint64(0); i <= uint64(window); i++ {
+		c := counter + i
+		if c < counter {
+			break
+		}
+
+		if h.match(st, passcode, c) {
+			return c, nil
+		}
+	}
+	return 0, ErrCodeIsNotValid
+}
+
+// truncate returns a 31-bit value from the HMAC result.
+// See: http://tools.ietf.org/html/rfc4226#section-5.4
+func truncate(sum []byte) int64 {
+	offset := sum[len(sum)-1] & 0xf
+	var value int64
+	value |= int64(sum[offset]&0x7f) << 24
+	value |= int64(sum[offset+1]&0xff) << 16
+	value |= int64(sum[offset+2]&0xff) << 8
+	value |= int64(sum[offset+3] & 0xff)
+	return value
+}
+
+// truncateLong returns a 63-bit value from the HMAC result.
+// Like truncate the offset is the low 4 bits of the last byte but modulo
+// len(sum)-8, so the 8 bytes read never include the last byte.
+func truncateLong(sum []byte) uint64 {
+	offset := int(sum[len(sum)-1]&0xf) % (len(sum) - 8)
+	return binary.BigEndian.Uint64(sum[offset:]) & (1<<63 - 1)
+}
+
+const (
+	// maxDigits is the max number of decimal digits for the 31-bit truncation.
+	maxDigits = 9
+	// maxLongDigits is the max number of decimal digits for the 63-bit truncation.
+	maxLongDigits = 12
+)
+
+// encoderBase returns the number of symbols of the encoder,
+// false for custom encoders.
+func encoderBase(enc Encoder) (int, bool) {
+	switch enc := enc.(type) {
+	case nil:
+		return 10, true
+	case AlphabetEncoder:
+		return len(enc), true
+	case steamEncoder:
+		return len(steamAlphabet), true
+	default:
+		return 0, false
+	}
+}
+
+// validEncoder reports whether the encoder has at least 2 symbols
+// and AlphabetEncoder has unique ASCII ones. Custom encoders are not checked.
+func validEncoder(enc Encoder) bool {
+	if a, ok := enc.(AlphabetEncoder); ok {
+		return a.valid()
+	}
+	base, ok := encoderBase(enc)
+	return !ok || base >= 2
+}
+
+// validDigits reports whether the codes of the digits length are supported:
+// the number of codes must not exceed the 31-bit truncated value or
+// the 63-bit one with long codes, so every symbol depends on the HMAC.
+// Decimal long codes are limited to maxLongDigits. Custom encoders are not checked.
+func validDigits(digits uint, enc Encoder, long bool) bool {
+	base, ok := encoderBase(enc)
+	if !ok || base < 2 {
+		return true
+	}
+	if base == 10 && long && digits > maxLongDigits {
+		return false
+	}
+
+	limit := uint64(1) << 31
+	if long {
+		limit = 1 << 63
+	}
+	for codes := uint64(1); digits > 0; digits-- {
+		if codes > limit/uint64(base) {
+			return false
+		}
+		codes *= uint64(base)
+	}
+	return true
+}
+
+// formatCode returns value as a decimal code of the given digits.
+func formatCode(value int64, digits uint) string {
+	code := make([]byte, digits)
+	encodeDecimal(code, uint64(value))
+	return string(code)
+}
+
+// pow10 contains powers of 10 that fit into uint64.
+var pow10 = [...]uint64{
+	1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+}
+
+// encodeDecimal is EncoderDecimal without the interface call,
+// dst is filled with the last len(dst) decimal digits of the value.
+func encodeDecimal(dst []byte, value uint64) {
+	if len(dst) < len(pow10) {
+		value %= pow10[len(dst)]
+	}
+	for i := len(dst) - 1; i >= 0; i-- {
+		dst[i] = byte('0' + value%10)
+		value /= 10
+	}
+}
@@ -41,6 +41,63 @@ func TestHOTP(t *testing.T) {
 	}
 }
 
+func TestHOTPValidateCounter(t *testing.T) {
+	hotp, err := NewHOTP(HOTPConfig{
+		Algo:      AlgorithmSHA1,
+		Digits:    6,
+		Issuer:    "cristalhq",
+		LookAhead: 3,
+	})
+	mustOk(t, err)
+
+	counter, err := hotp.ValidateCounter("755224", 0, secretSha1)
+	mustOk(t, err)
+	mustEqual(t, counter, uint64(0))
+
+	counter, err = hotp.ValidateCounter("338314", 2, secretSha1)
+	mustOk(t, err)
+	mustEqual(t, counter, uint64(4))
+
+	counter, err = hotp.ValidateCounter("254676", 2, secretSha1)
+	mustOk(t, err)
+	mustEqual(t, counter, uint64(5))
+
+	_, err = hotp.ValidateCounter("287922", 2, secretSha1)
+	mustEqual(t, err, ErrCodeIsNotValid)
+
+	_, err = hotp.ValidateCounter("755224", 1, secretSha1)
+	mustEqual(t, err, ErrCodeIsNotValid)
+
+	_, err = hotp.ValidateCounter("75522", 0, secretSha1)
+	mustEqual(t, err, ErrCodeLengthMismatch)
+}
+
+func TestHOTPResync(t *testing.T) {
+	hotp, err := NewHOTP(HOTPConfig{
+		Algo:   AlgorithmSHA1,
+		Digits: 6,
+		Issuer: "cristalhq",
+	})
+	mustOk(t, err)
+
+	counter, err := hotp.Resync("162583", "399871", 0, 10, secretSha1)
+	mustOk(t, err)
+	mustEqual(t, counter, uint64(8))
+
+	counter, err = hotp.Resync("755224", "287082", 0, 0, secretSha1)
+	mustOk(t, err)
+	mustEqual(t, counter, uint64(1))
+
+	_, err = hotp.Resync("162583", "520489", 0, 10, secretSha1)
+	mustEqual(t, err, ErrCodeIsNotValid)
+
+	_, err = hotp.Resync("162583", "399871", 0, 6, secretSha1)
+	mustEqual(t, err, ErrCodeIsNotValid)
+
+	_, err = hotp.Resync("162583", "39987", 0, 10, secretSha1)
+	mustEqual(t, err, ErrCodeLengthMismatch)
+}
+
 func TestNewHOTP(t *testing.T) {
 	_, err := NewHOTP(HOTPConfig{
 		Algo:   0,
@@ -69,6 +126,71 @@ func TestNewHOTP(t *testing.T) {
 		Issuer: "",
 	})
 	mustEqual(t, err, ErrEmptyIssuer)
+
+	_, err = NewHOTP(HOTPConfig{
+		Algo:   1,
+		Digits: 10,
+		Issuer: "cristalhq",
+	})
+	mustEqual(t, err, ErrDigitsNotValid)
+
+	_, err = NewHOTP(HOTPConfig{
+		Algo:      1,
+		Digits:    13,
+		Issuer:    "cristalhq",
+		LongCodes: true,
+	})
+	mustEqual(t, err, ErrDigitsNotValid)
+}
+
+func TestHOTPLongCodes(t *testing.T) {
+	for _, digits := range []uint{6, 10, 12} {
+		hotp, err := NewHOTP(HOTPConfig{
+			Algo:      AlgorithmSHA1,
+			Digits:    digits,
+			Issuer:    "cristalhq",
+			LongCodes: true,
+		})
+		mustOk(t, err)
+
+		code, err := hotp.GenerateCode(42, "JBSWY3DPEHPK3PXP")
+		mustOk(t, err)
+		mustEqual(t, code, "557551706466408"[15-digits:])
+
+		err = hotp.Validate(code, 42, "JBSWY3DPEHPK3PXP")
+		mustOk(t, err)
+	}
+}
+
+func TestTruncateLong(t *testing.T) {
+	// Offset 0xf is reduced to 3 for SHA1, so the last byte is never used.
+	sum := []byte{
+		0x00, 0x01, 0x02, 0xff, 0xfe, 0xfd, 0xfc, 0xfb,
+		0xfa, 0xf9, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x0f,
+	}
+	mustEqual(t, truncateLong(sum), uint64(0x7ffefdfcfbfaf9f8))
+
+	sum[len(sum)-1] = 0x02
+	mustEqual(t, truncateLong(sum), uint64(0x02fffefdfcfbfaf9))
+}
+
+func TestHOTPConfig(t *testing.T) {
+	cfg := HOTPConfig{
+		Algo:      AlgorithmSHA512,
+		Digits:    7,
+		Issuer:    "cristalhq",
+		Encoder:   EncoderHex,
+		LookAhead: 5,
+	}
+	hotp, err := NewHOTP(cfg)
+	mustOk(t, err)
+	mustEqual(t, hotp.Config(), cfg)
+
+	// Config is a copy.
+	got := hotp.Config()
+	got.Digits = 6
+	mustEqual(t, hotp.Digits(), uint(7))
 }
 
 func TestHOTPGenerateURL(t *testing.T) {
@@ -80,10 +202,34 @@ func TestHOTPGenerateURL(t *testing.T) {
 	mustOk(t, err)
 
 	url := hotp.GenerateURL("alice@bob.com", []byte("SECRET_STRING"))
-	mustEqual(t, url, "otpauth://hotp/cristalhq:alice@bob.com?algorithm=SHA1&digits=8&issuer=cristalhq&secret=KNCUGUSFKRPVGVCSJFHEO")
+	mustEqual(t, url, "otpauth://hotp/cristalhq:alice@bob.com?algorithm=SHA1&counter=0&digits=8&issuer=cristalhq&secret=KNCUGUSFKRPVGVCSJFHEO")
 
 	url = hotp.GenerateURL("bob@alice.com", []byte("SECRET_STRING"))
-	mustEqual(t, url, "otpauth://hotp/cristalhq:bob@alice.com?algorithm=SHA1&digits=8&issuer=cristalhq&secret=KNCUGUSFKRPVGVCSJFHEO")
+	mustEqual(t, url, "otpauth://hotp/cristalhq:bob@alice.com?algorithm=SHA1&counter=0&digits=8&issuer=cristalhq&secret=KNCUGUSFKRPVGVCSJFHEO")
+
+	url = hotp.GenerateURLWithCounter("alice@bob.com", 42, []byte("SECRET_STRING"))
+	mustEqual(t, url, "otpauth://hotp/cristalhq:alice@bob.com?algorithm=SHA1&counter=42&digits=8&issuer=cristalhq&secret=KNCUGUSFKRPVGVCSJFHEO")
+
+	key, err := ParseKeyFromURLStrict(url)
+	mustOk(t, err)
+	counter, ok := key.LookupCounter()
+	mustEqual(t, counter, uint64(42))
+	mustEqual(t, ok, true)
+
+	strict, err := hotp.GenerateURLStrict("alice@bob.com", 42, []byte("SECRET_STRING"))
+	mustOk(t, err)
+	mustEqual(t, strict, url)
+
+	hotp, err = NewHOTP(HOTPConfig{
+		Algo:      AlgorithmSHA1,
+		Digits:    10,
+		Issuer:    "cristalhq",
+		LongCodes: true,
+	})
+	mustOk(t, err)
+
+	_, err = hotp.GenerateURLStrict("alice@bob.com", 42, []byte("SECRET_STRING"))
+	mustEqual(t, err, ErrLongCodesInURL)
 }
 
 func BenchmarkHOTP_GenerateURL(b *testing.B) {
@@ -146,3 +292,61 @@ func BenchmarkHOTP_Validate(b *testing.B) {
 		mustOk(b, err)
 	}
 }
+
+var benchAlgos = []struct {
+	algo   Algorithm
+	secret string
+}{
+	{AlgorithmSHA1, secretSha1},
+	{AlgorithmSHA256, secretSha256},
+	{AlgorithmSHA512, secretSha512},
+}
+
+func BenchmarkHOTP_AppendCode(b *testing.B) {
+	for _, bc := range benchAlgos {
+		b.Run(bc.algo.String(), func(b *testing.B) {
+			hotp, err := NewHOTP(HOTPConfig{
+				Algo:   bc.algo,
+				Digits: 8,
+				Issuer: "cristalhq",
+			})
+			mustOk(b, err)
+
+			secret, err := NewSecretFromBase32(bc.secret)
+			mustOk(b, err)
+			buf := make([]byte, 0, 8)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				buf = hotp.AppendCode(buf[:0], uint64(i), secret)
+			}
+		})
+	}
+}
+
+func BenchmarkHOTP_ValidateSecret(b *testing.B) {
+	for _, bc := range benchAlgos {
+		b.Run(bc.algo.String(), func(b *testing.B) {
+			hotp, err := NewHOTP(HOTPConfig{
+				Algo:   bc.algo,
+				Digits: 8,
+				Issuer: "cristalhq",
+			})
+			mustOk(b, err)
+
+			secret, err := NewSecretFromBase32(bc.secret)
+			mustOk(b, err)
+			passcode := hotp.GenerateCodeSecret(1, secret)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				err := hotp.ValidateSecret(passcode, 1, secret)
+				mustOk(b, err)
+			}
+		})
+	}
+}
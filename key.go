@@ -0,0 +1,180 @@
+package otp
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NewKey creates new Key of the given type ("hotp", "totp" or "steam")
+// for the issuer and account. Other parameters are set with the setters:
+//
+//	key := otp.NewKey("totp", "ACME Co", "john@example.com").
+//		SetSecret(secret).
+//		SetDigits(8)
+//
+// The issuer is set both as the label prefix and the issuer parameter,
+// empty issuer is omitted. Use String to get the otpauth URL.
+func NewKey(typ, issuer, account string) *Key {
+	k := &Key{
+		url: &url.URL{
+			Scheme: "otpauth",
+			Host:   typ,
+		},
+		values: url.Values{},
+		dirty:  true,
+	}
+	k.setLabel(issuer, account)
+	if issuer != "" {
+		k.values.Set("issuer", issuer)
+	}
+	return k
+}
+
+// SetIssuer sets the issuer.
+func (k *Key) SetIssuer(issuer string) *Key {
+	_, account := k.label()
+	k.setLabel(issuer, account)
+	if issuer != "" {
+		k.values.Set("issuer", issuer)
+	} else {
+		k.values.Del("issuer")
+	}
+	k.dirty = true
+	return k
+}
+
+// SetAccount sets the account name.
+func (k *Key) SetAccount(account string) *Key {
+	issuer, _ := k.label()
+	k.setLabel(issuer, account)
+	return k
+}
+
+// SetSecret sets the secret, it is encoded as unpadded base32.
+func (k *Key) SetSecret(secret []byte) *Key {
+	return k.SetParam("secret", b32Enc(secret))
+}
+
+// SetAlgorithm sets the algorithm.
+func (k *Key) SetAlgorithm(algo Algorithm) *Key {
+	return k.SetParam("algorithm", algo.String())
+}
+
+// SetDigits sets the number of digits.
+func (k *Key) SetDigits(digits uint) *Key {
+	return k.SetParam("digits", atoi(uint64(digits)))
+}
+
+// SetPeriod sets the TOTP period in seconds.
+func (k *Key) SetPeriod(period uint64) *Key {
+	return k.SetParam("period", atoi(period))
+}
+
+// SetCounter sets the HOTP counter.
+func (k *Key) SetCounter(counter uint64) *Key {
+	return k.SetParam("counter", atoi(counter))
+}
+
+// SetImage sets the URL of the issuer logo, supported by some apps.
+func (k *Key) SetImage(image string) *Key {
+	return k.SetParam("image", image)
+}
+
+// SetColor sets the hex color like "FF0000" of the key, supported by some apps.
+func (k *Key) SetColor(color string) *Key {
+	return k.SetParam("color", color)
+}
+
+// SetLock sets whether the key requires an authentication to show the code,
+// supported by some apps.
+func (k *Key) SetLock(lock bool) *Key {
+	return k.SetParam("lock", strconv.FormatBool(lock))
+}
+
+// SetParam sets the query parameter, use it for vendor extensions.
+// Empty value removes the parameter.
+func (k *Key) SetParam(name, value string) *Key {
+	if value == "" {
+		k.values.Del(name)
+	} else {
+		k.values.Set(name, value)
+	}
+	k.dirty = true
+	return k
+}
+
+// Param returns the query parameter, like "image" or other vendor extensions.
+func (k *Key) Param(name string) string { return k.values.Get(name) }
+
+// encodeQuery is like url.Values.Encode but escapes spaces as "%20",
+// as in the Key URI Format, instead of "+".
+func encodeQuery(values url.Values) string {
+	n := 0
+	for name, vs := range values {
+		for _, value := range vs {
+			n += len(name) + len(value) + 2
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(n)
+	for _, name := range sortedKeys(values) {
+		for _, value := range values[name] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(queryEscape(name))
+			b.WriteByte('=')
+			b.WriteString(queryEscape(value))
+		}
+	}
+	return b.String()
+}
+
+// queryEscape escapes the query component as RFC 3986, with "%20" for spaces.
+func queryEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// setLabel sets "issuer:account" path with colons in both escaped,
+// so the separator is the only literal colon.
+func (k *Key) setLabel(issuer, account string) {
+	if issuer == "" {
+		k.url.Path = "/" + account
+		k.url.RawPath = "/" + escapeLabel(account)
+		return
+	}
+	k.url.Path = "/" + issuer + ":" + account
+	k.url.RawPath = "/" + escapeLabel(issuer) + ":" + escapeLabel(account)
+}
+
+// label returns the issuer prefix and the account from the path.
+// The separator is the first literal colon, if there is none
+// the first escaped one (like "Example%3Aalice") is used.
+func (k *Key) label() (issuer, account string) {
+	if k.url == nil {
+		return "", ""
+	}
+	p := strings.TrimPrefix(k.url.EscapedPath(), "/")
+	if i := strings.Index(p, ":"); i != -1 {
+		return unescapeLabel(p[:i]), unescapeLabel(p[i+1:])
+	}
+
+	p = strings.TrimPrefix(k.url.Path, "/")
+	if i := strings.Index(p, ":"); i != -1 {
+		return p[:i], p[i+1:]
+	}
+	return "", p
+}
+
+func escapeLabel(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), ":", "%3A")
+}
+
+func unescapeLabel(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
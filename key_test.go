@@ -0,0 +1,97 @@
+package otp
+
+import "testing"
+
+func TestNewKey(t *testing.T) {
+	key := NewKey("hotp", "ACME Co", "john@example.com").
+		SetSecret([]byte("12345678901234567890")).
+		SetAlgorithm(AlgorithmSHA256).
+		SetDigits(8).
+		SetCounter(42).
+		SetImage("https://example.com/logo.png").
+		SetColor("FF0000").
+		SetLock(true)
+
+	mustEqual(t, key.String(), "otpauth://hotp/ACME%20Co:john@example.com?algorithm=SHA256&color=FF0000&counter=42&digits=8&image=https%3A%2F%2Fexample.com%2Flogo.png&issuer=ACME%20Co&lock=true&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	mustOk(t, key.Validate())
+
+	parsed, err := ParseKeyFromURLStrict(key.String())
+	mustOk(t, err)
+	mustEqual(t, parsed.Type(), "hotp")
+	mustEqual(t, parsed.Issuer(), "ACME Co")
+	mustEqual(t, parsed.Account(), "john@example.com")
+	mustEqual(t, parsed.Secret(), secretSha1)
+	mustEqual(t, parsed.Algorithm(), AlgorithmSHA256)
+	mustEqual(t, parsed.Digits(), uint(8))
+	mustEqual(t, parsed.Counter(), uint64(42))
+	mustEqual(t, parsed.Param("image"), "https://example.com/logo.png")
+	mustEqual(t, parsed.Param("color"), "FF0000")
+	mustEqual(t, parsed.Param("lock"), "true")
+
+	key.SetParam("image", "").SetIssuer("").SetAccount("jane")
+	mustEqual(t, key.String(), "otpauth://hotp/jane?algorithm=SHA256&color=FF0000&counter=42&digits=8&lock=true&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	key.SetIssuer("Other")
+	mustEqual(t, key.Issuer(), "Other")
+	mustEqual(t, key.Account(), "jane")
+
+	key = NewKey("totp", "", "alice").SetSecret([]byte("12345678901234567890")).SetPeriod(60)
+	mustEqual(t, key.String(), "otpauth://totp/alice?period=60&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	mustEqual(t, key.Issuer(), "")
+	mustEqual(t, key.Period(), uint64(60))
+}
+
+func TestKeyLabel(t *testing.T) {
+	testCases := []struct {
+		issuer, account string
+		url             string
+	}{
+		{"ACME", "alice", "otpauth://totp/ACME:alice?issuer=ACME"},
+		{"AC:ME", "bob:1", "otpauth://totp/AC%3AME:bob%3A1?issuer=AC%3AME"},
+		{"Big Corp", "j doe", "otpauth://totp/Big%20Corp:j%20doe?issuer=Big%20Corp"},
+		{"Bücher", "Jöhn", "otpauth://totp/B%C3%BCcher:J%C3%B6hn?issuer=B%C3%BCcher"},
+		{"a/b", "c?d#e", "otpauth://totp/a%2Fb:c%3Fd%23e?issuer=a%2Fb"},
+		{"A+B &C", "x", "otpauth://totp/A+B%20&C:x?issuer=A%2BB%20%26C"},
+	}
+
+	for _, tc := range testCases {
+		key := NewKey("totp", tc.issuer, tc.account)
+		mustEqual(t, key.String(), tc.url)
+
+		parsed, err := ParseKeyFromURL(tc.url)
+		mustOk(t, err)
+		mustEqual(t, parsed.Issuer(), tc.issuer)
+		mustEqual(t, parsed.Account(), tc.account)
+		mustEqual(t, parsed.String(), tc.url)
+	}
+
+	// Escaped colon is the separator when there is no literal one.
+	key, err := ParseKeyFromURL("otpauth://totp/Example%3Aalice@google.com?secret=JBSWY3DPEHPK3PXP")
+	mustOk(t, err)
+	mustEqual(t, key.Issuer(), "Example")
+	mustEqual(t, key.Account(), "alice@google.com")
+}
+
+func TestKeyLookupCounter(t *testing.T) {
+	testCases := []struct {
+		url     string
+		counter uint64
+		ok      bool
+	}{
+		{"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=7", 7, true},
+		{"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=0", 0, true},
+		{"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP", 0, false},
+		{"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=", 0, false},
+		{"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=-1", 0, false},
+	}
+
+	for _, tc := range testCases {
+		key, err := ParseKeyFromURL(tc.url)
+		mustOk(t, err)
+
+		counter, ok := key.LookupCounter()
+		mustEqual(t, counter, tc.counter)
+		mustEqual(t, ok, tc.ok)
+		mustEqual(t, key.Counter(), tc.counter)
+	}
+}
@@ -0,0 +1,281 @@
+package otp
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Algorithm) MarshalText() ([]byte, error) {
+	if a >= algorithmMax {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// Empty text is AlgorithmUnknown.
+func (a *Algorithm) UnmarshalText(text []byte) error {
+	algo := parseAlgorithm(string(text))
+	if algo == AlgorithmUnknown && len(text) != 0 {
+		return ErrUnsupportedAlgorithm
+	}
+	*a = algo
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e SecretEncoding) MarshalText() ([]byte, error) {
+	if e < 0 || e >= secretEncodingMax {
+		return nil, ErrEncodingNotValid
+	}
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *SecretEncoding) UnmarshalText(text []byte) error {
+	for enc := SecretEncoding(0); enc < secretEncodingMax; enc++ {
+		if enc.String() == string(text) {
+			*e = enc
+			return nil
+		}
+	}
+	return ErrEncodingNotValid
+}
+
+// MarshalJSON implements json.Marshaler.
+// Encoder is marshaled by name, see EncoderName.
+func (cfg HOTPConfig) MarshalJSON() ([]byte, error) {
+	type config HOTPConfig
+	name, err := EncoderName(cfg.Encoder)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		config
+		Encoder string `json:",omitempty"`
+	}{config(cfg), name})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (cfg *HOTPConfig) UnmarshalJSON(b []byte) error {
+	type config HOTPConfig
+	v := struct {
+		*config
+		Encoder string
+	}{config: (*config)(cfg)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	enc, err := EncoderByName(v.Encoder)
+	if err != nil {
+		return err
+	}
+	cfg.Encoder = enc
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+// Encoder is marshaled by name, see EncoderName. Clock is not marshaled.
+func (cfg TOTPConfig) MarshalJSON() ([]byte, error) {
+	type config TOTPConfig
+	name, err := EncoderName(cfg.Encoder)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		config
+		Encoder string `json:",omitempty"`
+	}{config(cfg), name})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (cfg *TOTPConfig) UnmarshalJSON(b []byte) error {
+	type config TOTPConfig
+	v := struct {
+		*config
+		Encoder string
+	}{config: (*config)(cfg)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	enc, err := EncoderByName(v.Encoder)
+	if err != nil {
+		return err
+	}
+	cfg.Encoder = enc
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, the text is the otpauth URL.
+// JSON uses it too, so the key is a string in JSON. The zero Key is empty text.
+func (k Key) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseKeyFromURL.
+// Empty text is the zero Key.
+func (k *Key) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*k = Key{}
+		return nil
+	}
+	key, err := ParseKeyFromURL(string(text))
+	if err != nil {
+		return err
+	}
+	*k = *key
+	return nil
+}
+
+// keyBinaryVersion is the first byte of the binary Key encoding.
+const keyBinaryVersion = 1
+
+// Protobuf-like field numbers of the binary Key encoding.
+const (
+	keyType      = 1
+	keyIssuer    = 2 // Label prefix.
+	keyAccount   = 3
+	keySecret    = 4 // Raw bytes if the secret is canonical base32.
+	keyParam     = 5 // Name and value pair.
+	keyAlgorithm = 6
+	keyDigits    = 7
+	keyPeriod    = 8
+	keyCounter   = 9
+
+	keyParamName  = 1
+	keyParamValue = 2
+)
+
+var keyUintFields = map[string]int{
+	"digits":  keyDigits,
+	"period":  keyPeriod,
+	"counter": keyCounter,
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The encoding is compact: the secret is stored as raw bytes
+// and numeric parameters as varints. Only the first value of each
+// parameter is kept and the label is escaped as by NewKey.
+// Returns ErrKeyNotValid for the zero Key.
+func (k Key) MarshalBinary() ([]byte, error) {
+	if k.url == nil {
+		return nil, ErrKeyNotValid
+	}
+	issuer, account := k.label()
+
+	b := []byte{keyBinaryVersion}
+	b = appendProtoBytes(b, keyType, []byte(k.Type()))
+	b = appendProtoBytes(b, keyIssuer, []byte(issuer))
+	b = appendProtoBytes(b, keyAccount, []byte(account))
+
+	for _, name := range sortedKeys(k.values) {
+		value, _ := k.lookup(name)
+
+		switch name {
+		case "secret":
+			if raw, err := decodeSecret(value, SecretBase32); err == nil && b32Enc(raw) == value {
+				b = appendProtoBytes(b, keySecret, raw)
+				continue
+			}
+		case "algorithm":
+			if algo := parseAlgorithm(value); algo != AlgorithmUnknown && algo.String() == value {
+				b = appendProtoVarint(b, keyAlgorithm, uint64(algo))
+				continue
+			}
+		case "digits", "period", "counter":
+			if n, ok := parseCanonicalUint(value); ok {
+				b = appendProtoVarint(b, keyUintFields[name], n)
+				continue
+			}
+		}
+
+		param := appendProtoBytes(nil, keyParamName, []byte(name))
+		param = appendProtoBytes(param, keyParamValue, []byte(value))
+		b = appendProtoBytes(b, keyParam, param)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (k *Key) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != keyBinaryVersion {
+		return ErrKeyNotValid
+	}
+
+	var typ, issuer, account string
+	values := url.Values{}
+	err := walkProto(data[1:], func(field int, v uint64, b []byte) error {
+		switch field {
+		case keyType:
+			typ = string(b)
+		case keyIssuer:
+			issuer = string(b)
+		case keyAccount:
+			account = string(b)
+		case keySecret:
+			values.Set("secret", b32Enc(b))
+		case keyAlgorithm:
+			if Algorithm(v) == AlgorithmUnknown || Algorithm(v) >= algorithmMax {
+				return ErrKeyNotValid
+			}
+			values.Set("algorithm", Algorithm(v).String())
+		case keyDigits:
+			values.Set("digits", atoi(v))
+		case keyPeriod:
+			values.Set("period", atoi(v))
+		case keyCounter:
+			values.Set("counter", atoi(v))
+		case keyParam:
+			var name, value string
+			err := walkProto(b, func(field int, _ uint64, b []byte) error {
+				switch field {
+				case keyParamName:
+					name = string(b)
+				case keyParamValue:
+					value = string(b)
+				}
+				return nil
+			})
+			if err != nil || name == "" {
+				return ErrKeyNotValid
+			}
+			values.Set(name, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return ErrKeyNotValid
+	}
+
+	key := &Key{
+		url: &url.URL{
+			Scheme: "otpauth",
+			Host:   typ,
+		},
+		values: values,
+		dirty:  true,
+	}
+	key.setLabel(issuer, account)
+	*k = *key
+	return nil
+}
+
+// parseCanonicalUint parses the decimal number without sign and leading zeros.
+func parseCanonicalUint(s string) (uint64, bool) {
+	n, err := strconv.ParseUint(s, 10, 64)
+	return n, err == nil && atoi(n) == s
+}
+
+func sortedKeys(values url.Values) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,191 @@
+package otp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAlgorithmText(t *testing.T) {
+	for _, algo := range []Algorithm{AlgorithmUnknown, AlgorithmSHA1, AlgorithmSHA256, AlgorithmSHA512} {
+		text, err := algo.MarshalText()
+		mustOk(t, err)
+		mustEqual(t, string(text), algo.String())
+
+		var got Algorithm
+		mustOk(t, got.UnmarshalText(text))
+		mustEqual(t, got, algo)
+	}
+
+	b, err := json.Marshal(map[string]Algorithm{"algo": AlgorithmSHA256})
+	mustOk(t, err)
+	mustEqual(t, string(b), `{"algo":"SHA256"}`)
+
+	var algo Algorithm
+	mustOk(t, algo.UnmarshalText([]byte("sha512")))
+	mustEqual(t, algo, AlgorithmSHA512)
+
+	mustEqual(t, algo.UnmarshalText([]byte("MD5")), ErrUnsupportedAlgorithm)
+
+	_, err = Algorithm(42).MarshalText()
+	mustEqual(t, err, ErrUnsupportedAlgorithm)
+}
+
+func TestSecretEncodingText(t *testing.T) {
+	for enc := SecretBase32; enc <= SecretRaw; enc++ {
+		text, err := enc.MarshalText()
+		mustOk(t, err)
+
+		var got SecretEncoding
+		mustOk(t, got.UnmarshalText(text))
+		mustEqual(t, got, enc)
+	}
+
+	var enc SecretEncoding
+	mustEqual(t, enc.UnmarshalText([]byte("base58")), ErrEncodingNotValid)
+}
+
+func TestConfigJSON(t *testing.T) {
+	hotp := HOTPConfig{
+		Algo:      AlgorithmSHA256,
+		Digits:    8,
+		Issuer:    "cristalhq",
+		Encoder:   EncoderSteam,
+		LookAhead: 3,
+	}
+
+	b, err := json.Marshal(hotp)
+	mustOk(t, err)
+	mustEqual(t, string(b), `{"Algo":"SHA256","Digits":8,"Issuer":"cristalhq","LongCodes":false,"SecretEncoding":"base32","LookAhead":3,"Encoder":"steam"}`)
+
+	var gotHOTP HOTPConfig
+	mustOk(t, json.Unmarshal(b, &gotHOTP))
+	mustEqual(t, gotHOTP, hotp)
+
+	totp := TOTPConfig{
+		Algo:           AlgorithmSHA512,
+		Digits:         6,
+		Issuer:         "cristalhq",
+		SkewBackward:   1,
+		Interval:       1500 * time.Millisecond,
+		T0:             time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Encoder:        AlphabetEncoder("ab"),
+		SecretEncoding: SecretHex,
+		Clock:          NewFakeClock(time.Unix(42, 0)),
+	}
+
+	b, err = json.Marshal(totp)
+	mustOk(t, err)
+
+	var gotTOTP TOTPConfig
+	mustOk(t, json.Unmarshal(b, &gotTOTP))
+	totp.Clock = nil
+	mustEqual(t, gotTOTP, totp)
+
+	for _, enc := range []Encoder{nil, EncoderDecimal, EncoderHex, EncoderCrockford, EncoderLetters} {
+		b, err := json.Marshal(TOTPConfig{Encoder: enc})
+		mustOk(t, err)
+
+		var got TOTPConfig
+		mustOk(t, json.Unmarshal(b, &got))
+		mustEqual(t, got.Encoder, enc)
+	}
+
+	_, err = json.Marshal(HOTPConfig{Encoder: customEncoder{}})
+	mustEqual(t, err != nil, true)
+
+	err = json.Unmarshal([]byte(`{"Encoder":"base58"}`), &gotHOTP)
+	mustEqual(t, err, ErrEncoderNotValid)
+
+	err = json.Unmarshal([]byte(`{"Algo":"MD5"}`), &gotTOTP)
+	mustEqual(t, err != nil, true)
+}
+
+type customEncoder struct{}
+
+func (customEncoder) Encode(dst []byte, value uint64) {}
+
+func TestKeyMarshal(t *testing.T) {
+	urls := []string{
+		"otpauth://totp/ACME%20Co:john@example.com?algorithm=SHA256&digits=8&issuer=ACME%20Co&period=60&secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ",
+		"otpauth://hotp/AC%3AME:bob%3A1?color=FF0000&counter=0&image=https%3A%2F%2Fexample.com%2Flogo.png&lock=true&secret=JBSWY3DPEHPK3PXP",
+		"otpauth://steam/Steam:alice?secret=JBSWY3DPEHPK3PXP",
+		// Non-canonical values are kept as is.
+		"otpauth://totp/alice?algorithm=sha1&digits=06&secret=jbswy3dpehpk3pxp",
+	}
+
+	for _, u := range urls {
+		key, err := ParseKeyFromURL(u)
+		mustOk(t, err)
+
+		b, err := json.Marshal(key)
+		mustOk(t, err)
+		var s string
+		mustOk(t, json.Unmarshal(b, &s))
+		mustEqual(t, s, u)
+
+		var fromJSON Key
+		mustOk(t, json.Unmarshal(b, &fromJSON))
+		mustEqual(t, fromJSON.String(), u)
+
+		bin, err := key.MarshalBinary()
+		mustOk(t, err)
+
+		var fromBinary Key
+		mustOk(t, fromBinary.UnmarshalBinary(bin))
+		mustEqual(t, fromBinary.String(), u)
+	}
+
+	key, err := ParseKeyFromURL(urls[0])
+	mustOk(t, err)
+	bin, err := key.MarshalBinary()
+	mustOk(t, err)
+	mustEqual(t, len(bin), 81)
+
+	var k Key
+	mustEqual(t, k.UnmarshalBinary(nil), ErrKeyNotValid)
+	mustEqual(t, k.UnmarshalBinary([]byte{2}), ErrKeyNotValid)
+	mustEqual(t, k.UnmarshalBinary(bin[:len(bin)-1]), ErrKeyNotValid)
+}
+
+func TestKeyMarshalZero(t *testing.T) {
+	var v struct{ K Key }
+	b, err := json.Marshal(&v)
+	mustOk(t, err)
+	mustEqual(t, string(b), `{"K":""}`)
+
+	v.K = *NewKey("totp", "ACME", "alice")
+	mustOk(t, json.Unmarshal(b, &v))
+	mustEqual(t, v.K, Key{})
+	mustEqual(t, v.K.String(), "")
+
+	var k Key
+	_, err = k.MarshalBinary()
+	mustEqual(t, err, ErrKeyNotValid)
+
+	mustOk(t, k.UnmarshalText(nil))
+	mustEqual(t, k.Type(), "")
+	mustEqual(t, k.Issuer(), "")
+	mustEqual(t, k.Account(), "")
+	mustEqual(t, k.Validate(), ErrKeyNotValid)
+	_, err = k.GenerateCode(time.Unix(59, 0))
+	mustEqual(t, err, ErrTypeNotValid)
+}
+
+func TestKeyMarshalByValue(t *testing.T) {
+	key := NewKey("totp", "ACME", "alice").SetSecret([]byte("12345678901234567890"))
+
+	v := struct{ K Key }{*key}
+	b, err := json.Marshal(v)
+	mustOk(t, err)
+	mustEqual(t, string(b), `{"K":"otpauth://totp/ACME:alice?issuer=ACME\u0026secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"}`)
+
+	var got struct{ K Key }
+	mustOk(t, json.Unmarshal(b, &got))
+	mustEqual(t, got.K.String(), key.String())
+
+	bin, err := v.K.MarshalBinary()
+	mustOk(t, err)
+	mustOk(t, got.K.UnmarshalBinary(bin))
+	mustEqual(t, got.K.String(), key.String())
+}
@@ -0,0 +1,367 @@
+package otp
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrMigrationNotValid      = errors.New("migration payload is not valid")
+	ErrMigrationBatchNotValid = errors.New("migration batch is not valid")
+)
+
+// MigrationBatch represents a single Google Authenticator export QR code.
+type MigrationBatch struct {
+	Keys       []*Key
+	Version    int
+	BatchSize  int
+	BatchIndex int
+	BatchID    int
+}
+
+// Protobuf field numbers of the Google Authenticator MigrationPayload.
+const (
+	migrationParams     = 1
+	migrationVersion    = 2
+	migrationBatchSize  = 3
+	migrationBatchIndex = 4
+	migrationBatchID    = 5
+
+	paramSecret    = 1
+	paramName      = 2
+	paramIssuer    = 3
+	paramAlgorithm = 4
+	paramDigits    = 5
+	paramType      = 6
+	paramCounter   = 7
+)
+
+// ParseMigrationURL decodes otpauth-migration://offline?data= URL
+// exported by Google Authenticator.
+func ParseMigrationURL(s string) (*MigrationBatch, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "otpauth-migration" || u.Host != "offline" {
+		return nil, ErrMigrationNotValid
+	}
+
+	// Unescaped '+' is decoded as a space by the query parser.
+	data := strings.ReplaceAll(u.Query().Get("data"), " ", "+")
+	payload, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		payload, err = base64.RawStdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, ErrMigrationNotValid
+		}
+	}
+
+	batch := &MigrationBatch{}
+	err = walkProto(payload, func(field int, v uint64, b []byte) error {
+		switch field {
+		case migrationParams:
+			if b == nil {
+				return ErrMigrationNotValid
+			}
+			key, err := parseMigrationParams(b)
+			if err != nil {
+				return err
+			}
+			batch.Keys = append(batch.Keys, key)
+		case migrationVersion:
+			batch.Version = int(v)
+		case migrationBatchSize:
+			batch.BatchSize = int(v)
+		case migrationBatchIndex:
+			batch.BatchIndex = int(v)
+		case migrationBatchID:
+			batch.BatchID = int(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// ParseMigrationURLs decodes all the URLs of a multi-batch export.
+// URLs can be in any order but must belong to the same complete export.
+func ParseMigrationURLs(urls []string) ([]*Key, error) {
+	if len(urls) == 0 {
+		return nil, ErrMigrationBatchNotValid
+	}
+
+	batches := make([]*MigrationBatch, len(urls))
+	for _, s := range urls {
+		batch, err := ParseMigrationURL(s)
+		if err != nil {
+			return nil, err
+		}
+
+		size := batch.BatchSize
+		if size == 0 {
+			size = 1
+		}
+		if size != len(urls) || batch.BatchIndex < 0 || batch.BatchIndex >= size ||
+			batches[batch.BatchIndex] != nil {
+			return nil, ErrMigrationBatchNotValid
+		}
+		batches[batch.BatchIndex] = batch
+	}
+
+	var keys []*Key
+	for _, batch := range batches {
+		if batch == nil || batch.BatchID != batches[0].BatchID {
+			return nil, ErrMigrationBatchNotValid
+		}
+		keys = append(keys, batch.Keys...)
+	}
+	return keys, nil
+}
+
+// GenerateMigrationURLs encodes keys into Google Authenticator export URLs
+// with at most perBatch keys in each. Zero perBatch means a single URL.
+func GenerateMigrationURLs(keys []*Key, perBatch int) ([]string, error) {
+	if perBatch <= 0 {
+		perBatch = len(keys)
+	}
+
+	var params [][]byte
+	id := crc32.NewIEEE()
+	for _, key := range keys {
+		b, err := appendMigrationParams(nil, key)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, b)
+		id.Write(b)
+	}
+	batchID := uint64(id.Sum32() & 0x7fffffff)
+
+	size := (len(params) + perBatch - 1) / perBatch
+	if size == 0 {
+		size = 1
+	}
+
+	urls := make([]string, 0, size)
+	for i := 0; i < size; i++ {
+		var payload []byte
+		for j := i * perBatch; j < (i+1)*perBatch && j < len(params); j++ {
+			payload = appendProtoBytes(payload, migrationParams, params[j])
+		}
+		payload = appendProtoVarint(payload, migrationVersion, 1)
+		payload = appendProtoVarint(payload, migrationBatchSize, uint64(size))
+		payload = appendProtoVarint(payload, migrationBatchIndex, uint64(i))
+		payload = appendProtoVarint(payload, migrationBatchID, batchID)
+
+		v := url.Values{}
+		v.Set("data", base64.StdEncoding.EncodeToString(payload))
+		u := url.URL{
+			Scheme:   "otpauth-migration",
+			Host:     "offline",
+			RawQuery: v.Encode(),
+		}
+		urls = append(urls, u.String())
+	}
+	return urls, nil
+}
+
+func parseMigrationParams(b []byte) (*Key, error) {
+	var secret []byte
+	var name, issuer string
+	var algo, digits, typ, counter uint64
+
+	err := walkProto(b, func(field int, v uint64, b []byte) error {
+		switch field {
+		case paramSecret:
+			secret = b
+		case paramName:
+			name = string(b)
+		case paramIssuer:
+			issuer = string(b)
+		case paramAlgorithm:
+			algo = v
+		case paramDigits:
+			digits = v
+		case paramType:
+			typ = v
+		case paramCounter:
+			counter = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v := url.Values{}
+	v.Set("secret", b32Enc(secret))
+	if issuer != "" {
+		v.Set("issuer", issuer)
+	}
+
+	switch algo {
+	case 0:
+	case 1, 2, 3:
+		v.Set("algorithm", Algorithm(algo).String())
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	switch digits {
+	case 0:
+	case 1:
+		v.Set("digits", "6")
+	case 2:
+		v.Set("digits", "8")
+	default:
+		return nil, ErrDigitsNotValid
+	}
+
+	var host string
+	switch typ {
+	case 1:
+		host = "hotp"
+		v.Set("counter", atoi(counter))
+	case 0, 2:
+		host = "totp"
+	default:
+		return nil, ErrTypeNotValid
+	}
+
+	label := name
+	if issuer != "" && !strings.HasPrefix(name, issuer+":") {
+		label = issuer + ":" + name
+	}
+
+	u := &url.URL{
+		Scheme: "otpauth",
+		Host:   host,
+		Path:   "/" + label,
+	}
+	return &Key{url: u, values: v, dirty: true}, nil
+}
+
+func appendMigrationParams(b []byte, key *Key) ([]byte, error) {
+	secret, err := decodeSecret(key.Secret(), SecretBase32)
+	if err != nil {
+		return nil, ErrEncodingNotValid
+	}
+
+	var digits uint64
+	switch key.Digits() {
+	case 0:
+	case 6:
+		digits = 1
+	case 8:
+		digits = 2
+	default:
+		return nil, ErrDigitsNotValid
+	}
+
+	var typ uint64
+	switch key.Type() {
+	case "hotp":
+		typ = 1
+	case "totp":
+		typ = 2
+		// Google Authenticator supports only the default period.
+		if key.Period() != 30 {
+			return nil, ErrPeriodNotValid
+		}
+	default:
+		return nil, ErrTypeNotValid
+	}
+
+	name := key.Account()
+	if issuer := key.Issuer(); issuer != "" {
+		name = issuer + ":" + name
+	}
+
+	b = appendProtoBytes(b, paramSecret, secret)
+	b = appendProtoBytes(b, paramName, []byte(name))
+	b = appendProtoBytes(b, paramIssuer, []byte(key.Issuer()))
+	b = appendProtoVarint(b, paramAlgorithm, uint64(key.Algorithm()))
+	b = appendProtoVarint(b, paramDigits, digits)
+	b = appendProtoVarint(b, paramType, typ)
+	if typ == 1 {
+		b = appendProtoVarint(b, paramCounter, key.Counter())
+	}
+	return b, nil
+}
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// walkProto calls fn for each field of the protobuf message.
+// Varint fields are passed as v, length-delimited as b, other fields are skipped.
+func walkProto(b []byte, fn func(field int, v uint64, b []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrMigrationNotValid
+		}
+		b = b[n:]
+
+		field := int(tag >> 3)
+		switch tag & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return ErrMigrationNotValid
+			}
+			b = b[n:]
+			if err := fn(field, v, nil); err != nil {
+				return err
+			}
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return ErrMigrationNotValid
+			}
+			data := b[n : n+int(l)]
+			b = b[n+int(l):]
+			if err := fn(field, 0, data); err != nil {
+				return err
+			}
+		case wireFixed64:
+			if len(b) < 8 {
+				return ErrMigrationNotValid
+			}
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return ErrMigrationNotValid
+			}
+			b = b[4:]
+		default:
+			return ErrMigrationNotValid
+		}
+	}
+	return nil
+}
+
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	b = appendUvarint(b, uint64(field)<<3|wireVarint)
+	return appendUvarint(b, v)
+}
+
+func appendProtoBytes(b []byte, field int, v []byte) []byte {
+	b = appendUvarint(b, uint64(field)<<3|wireBytes)
+	b = appendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
@@ -0,0 +1,89 @@
+package otp
+
+import (
+	"testing"
+)
+
+func TestParseMigrationURL(t *testing.T) {
+	batch, err := ParseMigrationURL("otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC")
+	mustOk(t, err)
+	mustEqual(t, len(batch.Keys), 1)
+
+	key := batch.Keys[0]
+	mustEqual(t, key.Type(), "totp")
+	mustEqual(t, key.Issuer(), "Example")
+	mustEqual(t, key.Account(), "alice@google.com")
+	mustEqual(t, key.Secret(), "JBSWY3DPEHPK3PXP")
+	mustEqual(t, key.Algorithm(), AlgorithmUnknown)
+	mustEqual(t, key.Digits(), uint(0))
+
+	invalid := []string{
+		"otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP",
+		"otpauth-migration://online?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC",
+		"otpauth-migration://offline?data=!!!",
+		"otpauth-migration://offline?data=CjEKCkhlbGxv",
+	}
+	for _, s := range invalid {
+		_, err := ParseMigrationURL(s)
+		mustEqual(t, err, ErrMigrationNotValid)
+	}
+}
+
+func TestGenerateMigrationURLs(t *testing.T) {
+	urls := []string{
+		"otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example",
+		"otpauth://totp/cristalhq:bob@cristalhq.dev?secret=" + secretSha256 + "&issuer=cristalhq&algorithm=SHA256&digits=8",
+		"otpauth://hotp/carol@cristalhq.dev?secret=" + secretSha512 + "&algorithm=SHA512&digits=6&counter=42",
+	}
+
+	var keys []*Key
+	for _, s := range urls {
+		key, err := ParseKeyFromURL(s)
+		mustOk(t, err)
+		keys = append(keys, key)
+	}
+
+	for _, perBatch := range []int{0, 1, 2, 3} {
+		migration, err := GenerateMigrationURLs(keys, perBatch)
+		mustOk(t, err)
+
+		// Batches can be scanned in any order.
+		for i, j := 0, len(migration)-1; i < j; i, j = i+1, j-1 {
+			migration[i], migration[j] = migration[j], migration[i]
+		}
+
+		imported, err := ParseMigrationURLs(migration)
+		mustOk(t, err)
+		mustEqual(t, len(imported), len(keys))
+
+		for i, key := range imported {
+			mustEqual(t, key.Type(), keys[i].Type())
+			mustEqual(t, key.Issuer(), keys[i].Issuer())
+			mustEqual(t, key.Account(), keys[i].Account())
+			mustEqual(t, key.Secret(), keys[i].Secret())
+			mustEqual(t, key.Algorithm(), keys[i].Algorithm())
+			mustEqual(t, key.Digits(), keys[i].Digits())
+			mustEqual(t, key.Counter(), keys[i].Counter())
+		}
+	}
+
+	migration, err := GenerateMigrationURLs(keys, 2)
+	mustOk(t, err)
+	mustEqual(t, len(migration), 2)
+
+	_, err = ParseMigrationURLs(migration[:1])
+	mustEqual(t, err, ErrMigrationBatchNotValid)
+
+	_, err = ParseMigrationURLs([]string{migration[0], migration[0]})
+	mustEqual(t, err, ErrMigrationBatchNotValid)
+
+	other, err := GenerateMigrationURLs(keys[1:], 1)
+	mustOk(t, err)
+	_, err = ParseMigrationURLs([]string{migration[0], other[1]})
+	mustEqual(t, err, ErrMigrationBatchNotValid)
+
+	key, err := ParseKeyFromURL("otpauth://totp/alice@google.com?secret=JBSWY3DPEHPK3PXP&period=60")
+	mustOk(t, err)
+	_, err = GenerateMigrationURLs([]*Key{key}, 0)
+	mustEqual(t, err, ErrPeriodNotValid)
+}
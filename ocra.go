@@ -0,0 +1,299 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOCRASuiteNotValid    = errors.New("ocra suite is not valid")
+	ErrQuestionNotValid     = errors.New("question is not valid")
+	ErrPasswordHashNotValid = errors.New("password hash is not valid")
+	ErrSessionNotValid      = errors.New("session information is not valid")
+)
+
+// OCRA represents OCRA codes generator and validator.
+// See: https://datatracker.ietf.org/doc/html/rfc6287
+type OCRA struct {
+	suite OCRASuite
+}
+
+// OCRASuite represents a parsed OCRA suite like "OCRA-1:HOTP-SHA1-6:QN08".
+type OCRASuite struct {
+	raw string
+
+	Algo           Algorithm
+	Digits         uint // Zero means no truncation, the code is the hex HMAC.
+	Counter        bool
+	QuestionFormat byte // 'A', 'N' or 'H'.
+	QuestionLength uint
+	PasswordAlgo   Algorithm // AlgorithmUnknown when password is not used.
+	SessionLength  uint      // Zero when session information is not used.
+	TimeStep       time.Duration
+}
+
+// OCRAInput represents the variable part of the OCRA DataInput.
+type OCRAInput struct {
+	Counter      uint64
+	Question     string
+	PasswordHash []byte
+	Session      []byte
+	Time         time.Time
+}
+
+// ParseOCRASuite parses OCRA suite string.
+// See: https://datatracker.ietf.org/doc/html/rfc6287#section-6
+func ParseOCRASuite(s string) (OCRASuite, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] != "OCRA-1" {
+		return OCRASuite{}, ErrOCRASuiteNotValid
+	}
+
+	suite := OCRASuite{raw: s}
+
+	crypto := strings.Split(parts[1], "-")
+	if len(crypto) != 3 || crypto[0] != "HOTP" {
+		return OCRASuite{}, ErrOCRASuiteNotValid
+	}
+	suite.Algo = parseAlgorithm(crypto[1])
+	if suite.Algo == AlgorithmUnknown {
+		return OCRASuite{}, ErrUnsupportedAlgorithm
+	}
+	digits, err := strconv.ParseUint(crypto[2], 10, 8)
+	// Zero digits means no truncation, see: https://datatracker.ietf.org/doc/html/rfc6287#section-5.3
+	if err != nil || digits != 0 && (digits < 4 || digits > 10) {
+		return OCRASuite{}, ErrOCRASuiteNotValid
+	}
+	suite.Digits = uint(digits)
+
+	inputs := strings.Split(parts[2], "-")
+	if len(inputs) > 0 && inputs[0] == "C" {
+		suite.Counter = true
+		inputs = inputs[1:]
+	}
+
+	// Question is the only mandatory data input.
+	if len(inputs) == 0 || len(inputs[0]) != 4 || inputs[0][0] != 'Q' {
+		return OCRASuite{}, ErrOCRASuiteNotValid
+	}
+	switch q := inputs[0]; q[1] {
+	case 'A', 'N', 'H':
+		suite.QuestionFormat = q[1]
+		n, err := strconv.ParseUint(q[2:], 10, 8)
+		if err != nil || n < 4 || n > 64 {
+			return OCRASuite{}, ErrOCRASuiteNotValid
+		}
+		suite.QuestionLength = uint(n)
+	default:
+		return OCRASuite{}, ErrOCRASuiteNotValid
+	}
+	inputs = inputs[1:]
+
+	if len(inputs) > 0 && strings.HasPrefix(inputs[0], "P") {
+		suite.PasswordAlgo = parseAlgorithm(inputs[0][1:])
+		if suite.PasswordAlgo == AlgorithmUnknown {
+			return OCRASuite{}, ErrUnsupportedAlgorithm
+		}
+		inputs = inputs[1:]
+	}
+
+	if len(inputs) > 0 && strings.HasPrefix(inputs[0], "S") {
+		n, err := strconv.ParseUint(inputs[0][1:], 10, 16)
+		if err != nil || len(inputs[0]) != 4 || n == 0 {
+			return OCRASuite{}, ErrOCRASuiteNotValid
+		}
+		suite.SessionLength = uint(n)
+		inputs = inputs[1:]
+	}
+
+	if len(inputs) > 0 && strings.HasPrefix(inputs[0], "T") {
+		step, err := parseTimeStep(inputs[0][1:])
+		if err != nil {
+			return OCRASuite{}, err
+		}
+		suite.TimeStep = step
+		inputs = inputs[1:]
+	}
+
+	if len(inputs) != 0 {
+		return OCRASuite{}, ErrOCRASuiteNotValid
+	}
+	return suite, nil
+}
+
+func (s OCRASuite) String() string { return s.raw }
+
+// NewOCRA creates new OCRA for the given suite.
+func NewOCRA(suite string) (*OCRA, error) {
+	s, err := ParseOCRASuite(suite)
+	if err != nil {
+		return nil, err
+	}
+	return &OCRA{suite: s}, nil
+}
+
+// Suite returns the OCRA suite.
+func (o *OCRA) Suite() OCRASuite { return o.suite }
+
+// GenerateCode for the given secret and input.
+func (o *OCRA) GenerateCode(secret string, input OCRAInput) (string, error) {
+	secretBytes, err := decodeSecret(secret, SecretBase32)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := o.dataInput(input)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(o.suite.Algo.Hash, secretBytes)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	if o.suite.Digits == 0 {
+		return hex.EncodeToString(sum), nil
+	}
+	code := formatCode(truncate(sum), o.suite.Digits)
+	return code, nil
+}
+
+// Validate the given passcode, secret and input.
+func (o *OCRA) Validate(passcode, secret string, input OCRAInput) error {
+	codeLen := int(o.suite.Digits)
+	if codeLen == 0 {
+		codeLen = 2 * o.suite.Algo.Hash().Size()
+	}
+	if len(passcode) != codeLen {
+		return ErrCodeLengthMismatch
+	}
+
+	code, err := o.GenerateCode(secret, input)
+	if err != nil {
+		return err
+	}
+
+	ok := subtle.ConstantTimeCompare([]byte(code), []byte(passcode))
+	if ok != 1 {
+		return ErrCodeIsNotValid
+	}
+	return nil
+}
+
+// dataInput builds OCRA DataInput.
+// See: https://datatracker.ietf.org/doc/html/rfc6287#section-5.1
+func (o *OCRA) dataInput(input OCRAInput) ([]byte, error) {
+	s := o.suite
+
+	data := make([]byte, 0, len(s.raw)+1+8+128+64+512+8)
+	data = append(data, s.raw...)
+	data = append(data, 0x00)
+
+	if s.Counter {
+		data = appendUint64(data, input.Counter)
+	}
+
+	question, err := s.question(input.Question)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, question...)
+
+	if s.PasswordAlgo != AlgorithmUnknown {
+		if len(input.PasswordHash) != s.PasswordAlgo.Hash().Size() {
+			return nil, ErrPasswordHashNotValid
+		}
+		data = append(data, input.PasswordHash...)
+	}
+
+	if s.SessionLength != 0 {
+		if len(input.Session) > int(s.SessionLength) {
+			return nil, ErrSessionNotValid
+		}
+		data = append(data, make([]byte, int(s.SessionLength)-len(input.Session))...)
+		data = append(data, input.Session...)
+	}
+
+	if s.TimeStep != 0 {
+		steps := input.Time.Unix() / int64(s.TimeStep/time.Second)
+		data = appendUint64(data, uint64(steps))
+	}
+	return data, nil
+}
+
+// question returns the 128 bytes of the question field.
+func (s OCRASuite) question(q string) ([]byte, error) {
+	if q == "" {
+		return nil, ErrQuestionNotValid
+	}
+
+	var b []byte
+	switch s.QuestionFormat {
+	case 'A':
+		b = []byte(q)
+	case 'N':
+		n, ok := new(big.Int).SetString(q, 10)
+		if !ok || n.Sign() < 0 {
+			return nil, ErrQuestionNotValid
+		}
+		b = hexLeftAligned(n.Text(16))
+	case 'H':
+		b = hexLeftAligned(q)
+	}
+	if b == nil || len(b) > 128 {
+		return nil, ErrQuestionNotValid
+	}
+
+	buf := make([]byte, 128)
+	copy(buf, b)
+	return buf, nil
+}
+
+// hexLeftAligned decodes hex string padding it with '0' on the right.
+// Returns nil if the string is not a valid hex.
+func hexLeftAligned(s string) []byte {
+	if len(s)%2 == 1 {
+		s += "0"
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// parseTimeStep parses time step like "30S", "1M" or "48H".
+func parseTimeStep(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, ErrOCRASuiteNotValid
+	}
+
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 8)
+	if err != nil {
+		return 0, ErrOCRASuiteNotValid
+	}
+
+	switch unit := s[len(s)-1]; {
+	case unit == 'S' && n >= 1 && n <= 59:
+		return time.Duration(n) * time.Second, nil
+	case unit == 'M' && n >= 1 && n <= 59:
+		return time.Duration(n) * time.Minute, nil
+	case unit == 'H' && n >= 1 && n <= 48:
+		return time.Duration(n) * time.Hour, nil
+	default:
+		return 0, ErrOCRASuiteNotValid
+	}
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
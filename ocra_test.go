@@ -0,0 +1,192 @@
+package otp
+
+import (
+	"crypto/sha1"
+	"testing"
+	"time"
+)
+
+func TestOCRA(t *testing.T) {
+	pin := sha1.Sum([]byte("1234"))
+	at := time.Unix(0x132d0b6*60, 0).UTC()
+
+	// See: https://datatracker.ietf.org/doc/html/rfc6287#appendix-C
+	ocraRFCTestCases := []struct {
+		suite  string
+		secret string
+		input  OCRAInput
+		code   string
+	}{
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "00000000"}, "237653"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "11111111"}, "243178"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "22222222"}, "653583"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "33333333"}, "740991"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "44444444"}, "608993"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "55555555"}, "388898"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "66666666"}, "816933"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "77777777"}, "224598"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "88888888"}, "750600"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", secretSha1, OCRAInput{Question: "99999999"}, "294470"},
+
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 0, Question: "12345678", PasswordHash: pin[:]}, "65347737"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 1, Question: "12345678", PasswordHash: pin[:]}, "86775851"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 2, Question: "12345678", PasswordHash: pin[:]}, "78192410"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 3, Question: "12345678", PasswordHash: pin[:]}, "71565254"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 4, Question: "12345678", PasswordHash: pin[:]}, "10104329"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 5, Question: "12345678", PasswordHash: pin[:]}, "65983500"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 6, Question: "12345678", PasswordHash: pin[:]}, "70069104"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 7, Question: "12345678", PasswordHash: pin[:]}, "91771096"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 8, Question: "12345678", PasswordHash: pin[:]}, "75011558"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", secretSha256, OCRAInput{Counter: 9, Question: "12345678", PasswordHash: pin[:]}, "08522129"},
+
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", secretSha256, OCRAInput{Question: "00000000", PasswordHash: pin[:]}, "83238735"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", secretSha256, OCRAInput{Question: "11111111", PasswordHash: pin[:]}, "01501458"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", secretSha256, OCRAInput{Question: "22222222", PasswordHash: pin[:]}, "17957585"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", secretSha256, OCRAInput{Question: "33333333", PasswordHash: pin[:]}, "86776967"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", secretSha256, OCRAInput{Question: "44444444", PasswordHash: pin[:]}, "86807031"},
+
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 0, Question: "00000000"}, "07016083"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 1, Question: "11111111"}, "63947962"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 2, Question: "22222222"}, "70123924"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 3, Question: "33333333"}, "25341727"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 4, Question: "44444444"}, "33203315"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 5, Question: "55555555"}, "34205738"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 6, Question: "66666666"}, "44343969"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 7, Question: "77777777"}, "51946085"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 8, Question: "88888888"}, "20403879"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", secretSha512, OCRAInput{Counter: 9, Question: "99999999"}, "31409299"},
+
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", secretSha512, OCRAInput{Question: "00000000", Time: at}, "95209754"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", secretSha512, OCRAInput{Question: "11111111", Time: at}, "55907591"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", secretSha512, OCRAInput{Question: "22222222", Time: at}, "22048402"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", secretSha512, OCRAInput{Question: "33333333", Time: at}, "24218844"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", secretSha512, OCRAInput{Question: "44444444", Time: at}, "36209546"},
+
+		// Mutual challenge-response, server computation.
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "CLI22220SRV11110"}, "28247970"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "CLI22221SRV11111"}, "01984843"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "CLI22222SRV11112"}, "65387857"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "CLI22223SRV11113"}, "03351211"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "CLI22224SRV11114"}, "83412541"},
+
+		// Mutual challenge-response, client computation.
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SRV11110CLI22220"}, "15510767"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SRV11111CLI22221"}, "90175646"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SRV11112CLI22222"}, "33777207"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SRV11113CLI22223"}, "95285278"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SRV11114CLI22224"}, "28934924"},
+
+		{"OCRA-1:HOTP-SHA512-8:QA08", secretSha512, OCRAInput{Question: "CLI22220SRV11110"}, "79496648"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", secretSha512, OCRAInput{Question: "CLI22221SRV11111"}, "76831980"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", secretSha512, OCRAInput{Question: "CLI22222SRV11112"}, "12250499"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", secretSha512, OCRAInput{Question: "CLI22223SRV11113"}, "90856481"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", secretSha512, OCRAInput{Question: "CLI22224SRV11114"}, "12761449"},
+
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", secretSha512, OCRAInput{Question: "SRV11110CLI22220", PasswordHash: pin[:]}, "18806276"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", secretSha512, OCRAInput{Question: "SRV11111CLI22221", PasswordHash: pin[:]}, "70020315"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", secretSha512, OCRAInput{Question: "SRV11112CLI22222", PasswordHash: pin[:]}, "01600026"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", secretSha512, OCRAInput{Question: "SRV11113CLI22223", PasswordHash: pin[:]}, "18951020"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", secretSha512, OCRAInput{Question: "SRV11114CLI22224", PasswordHash: pin[:]}, "32528969"},
+
+		// Plain signature.
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SIG10000"}, "53095496"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SIG11000"}, "04110475"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SIG12000"}, "31331128"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SIG13000"}, "76028668"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", secretSha256, OCRAInput{Question: "SIG14000"}, "46554205"},
+
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", secretSha512, OCRAInput{Question: "SIG1000000", Time: at}, "77537423"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", secretSha512, OCRAInput{Question: "SIG1100000", Time: at}, "31970405"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", secretSha512, OCRAInput{Question: "SIG1200000", Time: at}, "10235557"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", secretSha512, OCRAInput{Question: "SIG1300000", Time: at}, "95213541"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", secretSha512, OCRAInput{Question: "SIG1400000", Time: at}, "65360607"},
+	}
+
+	for _, tc := range ocraRFCTestCases {
+		ocra, err := NewOCRA(tc.suite)
+		mustOk(t, err)
+
+		code, err := ocra.GenerateCode(tc.secret, tc.input)
+		mustOk(t, err)
+		mustEqual(t, code, tc.code)
+
+		err = ocra.Validate(tc.code, tc.secret, tc.input)
+		mustOk(t, err)
+	}
+}
+
+func TestOCRANoTruncation(t *testing.T) {
+	ocra, err := NewOCRA("OCRA-1:HOTP-SHA1-0:QN08")
+	mustOk(t, err)
+	mustEqual(t, ocra.Suite().Digits, uint(0))
+
+	input := OCRAInput{Question: "00000000"}
+	code, err := ocra.GenerateCode(secretSha1, input)
+	mustOk(t, err)
+	mustEqual(t, code, "d216b1d33ccbb7cc1076895153fc70bcf3d987de")
+
+	mustOk(t, ocra.Validate(code, secretSha1, input))
+	mustEqual(t, ocra.Validate("12345678", secretSha1, input), ErrCodeLengthMismatch)
+}
+
+func TestParseOCRASuite(t *testing.T) {
+	suite, err := ParseOCRASuite("OCRA-1:HOTP-SHA256-8:C-QH40-PSHA512-S128-T30S")
+	mustOk(t, err)
+	mustEqual(t, suite.String(), "OCRA-1:HOTP-SHA256-8:C-QH40-PSHA512-S128-T30S")
+	mustEqual(t, suite.Algo, AlgorithmSHA256)
+	mustEqual(t, suite.Digits, uint(8))
+	mustEqual(t, suite.Counter, true)
+	mustEqual(t, suite.QuestionFormat, byte('H'))
+	mustEqual(t, suite.QuestionLength, uint(40))
+	mustEqual(t, suite.PasswordAlgo, AlgorithmSHA512)
+	mustEqual(t, suite.SessionLength, uint(128))
+	mustEqual(t, suite.TimeStep, 30*time.Second)
+
+	invalid := []string{
+		"",
+		"OCRA-2:HOTP-SHA1-6:QN08",
+		"OCRA-1:TOTP-SHA1-6:QN08",
+		"OCRA-1:HOTP-SHA1-3:QN08",
+		"OCRA-1:HOTP-SHA1-6:C",
+		"OCRA-1:HOTP-SHA1-6:QX08",
+		"OCRA-1:HOTP-SHA1-6:QN99",
+		"OCRA-1:HOTP-SHA1-6:QN08-S1",
+		"OCRA-1:HOTP-SHA1-6:QN08-T60M",
+		"OCRA-1:HOTP-SHA1-6:QN08-X",
+	}
+	for _, s := range invalid {
+		_, err := ParseOCRASuite(s)
+		mustEqual(t, err, ErrOCRASuiteNotValid)
+	}
+
+	_, err = ParseOCRASuite("OCRA-1:HOTP-MD5-6:QN08")
+	mustEqual(t, err, ErrUnsupportedAlgorithm)
+}
+
+func TestOCRAInputNotValid(t *testing.T) {
+	ocra, err := NewOCRA("OCRA-1:HOTP-SHA1-6:QN08-PSHA1-S064")
+	mustOk(t, err)
+
+	pin := sha1.Sum([]byte("1234"))
+
+	_, err = ocra.GenerateCode(secretSha1, OCRAInput{Question: "", PasswordHash: pin[:]})
+	mustEqual(t, err, ErrQuestionNotValid)
+
+	_, err = ocra.GenerateCode(secretSha1, OCRAInput{Question: "12ab", PasswordHash: pin[:]})
+	mustEqual(t, err, ErrQuestionNotValid)
+
+	_, err = ocra.GenerateCode(secretSha1, OCRAInput{Question: "1234", PasswordHash: pin[:4]})
+	mustEqual(t, err, ErrPasswordHashNotValid)
+
+	_, err = ocra.GenerateCode(secretSha1, OCRAInput{Question: "1234", PasswordHash: pin[:], Session: make([]byte, 65)})
+	mustEqual(t, err, ErrSessionNotValid)
+
+	code, err := ocra.GenerateCode(secretSha1, OCRAInput{Question: "1234", PasswordHash: pin[:], Session: []byte("session")})
+	mustOk(t, err)
+
+	err = ocra.Validate(code, secretSha1, OCRAInput{Question: "1235", PasswordHash: pin[:], Session: []byte("session")})
+	mustEqual(t, err, ErrCodeIsNotValid)
+
+	err = ocra.Validate("1234567", secretSha1, OCRAInput{Question: "1234", PasswordHash: pin[:]})
+	mustEqual(t, err, ErrCodeLengthMismatch)
+}
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -22,6 +23,18 @@ var (
 	ErrCodeLengthMismatch   = errors.New("code length mismatch")
 	ErrCodeIsNotValid       = errors.New("code is not valid")
 	ErrEncodingNotValid     = errors.New("encoding is not valid")
+	ErrSchemeNotValid       = errors.New("scheme is not valid")
+	ErrTypeNotValid         = errors.New("type is not valid")
+	ErrEmptyAccount         = errors.New("empty account")
+	ErrEmptySecret          = errors.New("empty secret")
+	ErrIssuerMismatch       = errors.New("issuer mismatch")
+	ErrNoCounter            = errors.New("required counter not set")
+	ErrDigitsNotValid       = errors.New("digits is not valid")
+	ErrCounterNotValid      = errors.New("counter is not valid")
+	ErrTimeNotValid         = errors.New("time is not valid")
+	ErrEncoderNotValid      = errors.New("encoder is not valid")
+	ErrKeyNotValid          = errors.New("key is not valid")
+	ErrLongCodesInURL       = errors.New("long codes are not supported in URL")
 )
 
 // Algorithm represents the hashing function to use for OTP.
@@ -69,6 +82,8 @@ func (a Algorithm) Hash() hash.Hash {
 type Key struct {
 	url    *url.URL
 	values url.Values
+	// dirty is set when values changed, String encodes them then.
+	dirty bool
 }
 
 // ParseKeyFromURL creates a new Key from the HOTP or TOTP URL.
@@ -85,10 +100,119 @@ func ParseKeyFromURL(s string) (*Key, error) {
 	return key, nil
 }
 
-func (k *Key) String() string { return k.url.String() }
+// ParseKeyFromURLStrict is like ParseKeyFromURL but also validates the URL
+// against the Key URI Format and returns an error for each violation.
+// See: https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func ParseKeyFromURLStrict(s string) (*Key, error) {
+	key, err := ParseKeyFromURL(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Validate the key against the Key URI Format.
+func (k *Key) Validate() error {
+	if k.url == nil {
+		return ErrKeyNotValid
+	}
+	if k.url.Scheme != "otpauth" {
+		return ErrSchemeNotValid
+	}
+
+	typ := k.Type()
+	if typ != "hotp" && typ != "totp" && typ != "steam" {
+		return ErrTypeNotValid
+	}
+
+	if k.Account() == "" {
+		return ErrEmptyAccount
+	}
+
+	secret := k.Secret()
+	if secret == "" {
+		return ErrEmptySecret
+	}
+	if _, err := decodeSecret(secret, SecretBase32); err != nil {
+		return ErrEncodingNotValid
+	}
 
-// Type returns "hotp" or "totp".
-func (k *Key) Type() string { return k.url.Host }
+	if issuer := k.values.Get("issuer"); issuer != "" {
+		if prefix, _ := k.label(); prefix != "" && prefix != issuer {
+			return ErrIssuerMismatch
+		}
+	}
+
+	if algorithm, ok := k.lookup("algorithm"); ok && parseAlgorithm(algorithm) == AlgorithmUnknown {
+		return ErrUnsupportedAlgorithm
+	}
+
+	if digits, ok := k.lookup("digits"); ok {
+		val, err := strconv.ParseUint(digits, 10, 32)
+		if err != nil || val == 0 || val > maxDigits {
+			return ErrDigitsNotValid
+		}
+	}
+
+	if period, ok := k.lookup("period"); ok {
+		val, err := strconv.ParseUint(period, 10, 64)
+		if err != nil || val == 0 {
+			return ErrPeriodNotValid
+		}
+	}
+
+	counter, ok := k.lookup("counter")
+	switch {
+	case ok:
+		if _, err := strconv.ParseUint(counter, 10, 64); err != nil {
+			return ErrCounterNotValid
+		}
+	case typ == "hotp":
+		return ErrNoCounter
+	}
+	return nil
+}
+
+// lookup returns the query parameter and reports whether it is present.
+func (k *Key) lookup(name string) (string, bool) {
+	vs, ok := k.values[name]
+	if !ok || len(vs) == 0 {
+		return "", false
+	}
+	return vs[0], true
+}
+
+// String returns the otpauth URL, empty for the zero Key.
+func (k Key) String() string {
+	if k.url == nil {
+		return ""
+	}
+	if !k.dirty {
+		return k.url.String()
+	}
+	u := *k.url
+	u.RawQuery = encodeQuery(k.values)
+	return u.String()
+}
+
+// Type returns "hotp", "totp" or "steam", empty for the zero Key.
+func (k *Key) Type() string {
+	if k.url == nil {
+		return ""
+	}
+	return k.url.Host
+}
+
+// Encoder returns the code encoder, like "steam", empty for decimal codes.
+func (k *Key) Encoder() string { return k.values.Get("encoder") }
+
+// IsSteam reports whether the key is for Steam Guard codes.
+func (k *Key) IsSteam() bool {
+	return k.Type() == "steam" || k.Type() == "totp" && strings.EqualFold(k.Encoder(), "steam")
+}
 
 // Secret returns the opaque secret for this Key.
 func (k *Key) Secret() string { return k.values.Get("secret") }
@@ -100,20 +224,14 @@ func (k *Key) Issuer() string {
 		return issuer
 	}
 
-	p := strings.TrimPrefix(k.url.Path, "/")
-	if i := strings.Index(p, ":"); i != -1 {
-		return p[:i]
-	}
-	return ""
+	issuer, _ = k.label()
+	return issuer
 }
 
 // Account returns the name of the user's account.
 func (k *Key) Account() string {
-	p := strings.TrimPrefix(k.url.Path, "/")
-	if i := strings.Index(p, ":"); i != -1 {
-		return p[i+1:]
-	}
-	return p
+	_, account := k.label()
+	return account
 }
 
 // Period returns a tiny int representing the rotation time in seconds.
@@ -137,33 +255,76 @@ func (k *Key) Digits() uint {
 	return 0
 }
 
-// Counter returns the counter value.
+// Counter returns the counter value, 0 if it is absent or not valid.
 func (k *Key) Counter() uint64 {
-	if counter := k.values.Get("counter"); counter != "" {
-		if val, err := strconv.ParseUint(counter, 10, 64); err == nil {
-			return val
-		}
+	counter, _ := k.LookupCounter()
+	return counter
+}
+
+// LookupCounter returns the counter value and reports whether
+// the counter is present and valid.
+func (k *Key) LookupCounter() (uint64, bool) {
+	counter, ok := k.lookup("counter")
+	if !ok {
+		return 0, false
 	}
-	return 0
+	val, err := strconv.ParseUint(counter, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return val, true
 }
 
 // Algorithm returns the algorithm type.
 func (k *Key) Algorithm() Algorithm {
-	if algorithm := k.values.Get("algorithm"); algorithm != "" {
-		switch strings.ToUpper(algorithm) {
-		case "SHA1":
-			return AlgorithmSHA1
-		case "SHA256":
-			return AlgorithmSHA256
-		case "SHA512":
-			return AlgorithmSHA512
+	return parseAlgorithm(k.values.Get("algorithm"))
+}
+
+// GenerateCode returns the code for the key: at the given time for TOTP
+// and for the key counter for HOTP.
+func (k *Key) GenerateCode(at time.Time) (string, error) {
+	switch k.Type() {
+	case "hotp":
+		hotp, err := NewHOTPFromKey(k)
+		if err != nil {
+			return "", err
+		}
+		return hotp.GenerateCode(k.Counter(), k.Secret())
+	case "totp", "steam":
+		totp, err := NewTOTPFromKey(k)
+		if err != nil {
+			return "", err
 		}
+		return totp.GenerateCode(k.Secret(), at)
+	default:
+		return "", ErrTypeNotValid
 	}
-	return AlgorithmUnknown
 }
 
-func b32Dec(s string) ([]byte, error) {
-	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+// algoDigits returns the algorithm and digits with the defaults applied.
+func (k *Key) algoDigits() (Algorithm, uint) {
+	algo := k.Algorithm()
+	if algo == AlgorithmUnknown {
+		algo = AlgorithmSHA1
+	}
+	digits := k.Digits()
+	if digits == 0 {
+		digits = 6
+	}
+	return algo, digits
+}
+
+func parseAlgorithm(s string) Algorithm {
+	switch strings.ToUpper(s) {
+	case "SHA1":
+		return AlgorithmSHA1
+	case "SHA256":
+		return AlgorithmSHA256
+	case "SHA512":
+		return AlgorithmSHA512
+	default:
+		return AlgorithmUnknown
+	}
 }
 
 func b32Enc(src []byte) string {
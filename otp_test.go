@@ -3,6 +3,7 @@ package otp
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 var (
@@ -128,6 +129,115 @@ func TestKey(t *testing.T) {
 	}
 }
 
+func TestParseKeyFromURLStrict(t *testing.T) {
+	valid := []string{
+		"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&issuer=Example",
+		"otpauth://totp/alice@bob.com?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://totp/ACME%20Co:john@example.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA1&digits=6&period=30",
+		"otpauth://hotp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&counter=0",
+	}
+	for _, s := range valid {
+		_, err := ParseKeyFromURLStrict(s)
+		mustOk(t, err)
+	}
+
+	testCases := []struct {
+		url string
+		err error
+	}{
+		{"http://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP", ErrSchemeNotValid},
+		{"otpauth://motp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP", ErrTypeNotValid},
+		{"otpauth://totp/?secret=JBSWY3DPEHPK3PXP", ErrEmptyAccount},
+		{"otpauth://totp/Example:alice@bob.com", ErrEmptySecret},
+		{"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PX1", ErrEncodingNotValid},
+		{"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&issuer=Other", ErrIssuerMismatch},
+		{"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&algorithm=MD5", ErrUnsupportedAlgorithm},
+		{"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&digits=six", ErrDigitsNotValid},
+		{"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&digits=0", ErrDigitsNotValid},
+		{"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&digits=10", ErrDigitsNotValid},
+		{"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&period=-30", ErrPeriodNotValid},
+		{"otpauth://totp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&period=0", ErrPeriodNotValid},
+		{"otpauth://hotp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP", ErrNoCounter},
+		{"otpauth://hotp/Example:alice@bob.com?secret=JBSWY3DPEHPK3PXP&counter=x", ErrCounterNotValid},
+	}
+	for _, tc := range testCases {
+		_, err := ParseKeyFromURLStrict(tc.url)
+		mustEqual(t, err, tc.err)
+	}
+}
+
+func TestKeyGenerateCode(t *testing.T) {
+	key, err := ParseKeyFromURL("otpauth://hotp/cristalhq:alice@bob.com?secret=" + secretSha1 + "&counter=3")
+	mustOk(t, err)
+
+	code, err := key.GenerateCode(time.Time{})
+	mustOk(t, err)
+	mustEqual(t, code, "969429")
+
+	hotp, err := NewHOTPFromKey(key)
+	mustOk(t, err)
+	mustEqual(t, hotp.cfg, HOTPConfig{Algo: AlgorithmSHA1, Digits: 6, Issuer: "cristalhq"})
+
+	key, err = ParseKeyFromURL("otpauth://totp/alice@bob.com?secret=" + secretSha256 + "&issuer=cristalhq&algorithm=SHA256&digits=8")
+	mustOk(t, err)
+
+	code, err = key.GenerateCode(time.Unix(1234567890, 0))
+	mustOk(t, err)
+	mustEqual(t, code, "91819424")
+
+	totp, err := NewTOTPFromKey(key)
+	mustOk(t, err)
+	mustEqual(t, totp.cfg, TOTPConfig{Algo: AlgorithmSHA256, Digits: 8, Issuer: "cristalhq", Period: 30, Skew: 1})
+
+	_, err = NewHOTPFromKey(key)
+	mustEqual(t, err, ErrTypeNotValid)
+
+	key, err = ParseKeyFromURL("otpauth://totp/alice@bob.com?secret=" + secretSha1)
+	mustOk(t, err)
+
+	totp, err = NewTOTPFromKey(key)
+	mustOk(t, err)
+	mustEqual(t, totp.cfg, TOTPConfig{Algo: AlgorithmSHA1, Digits: 6, Period: 30, Skew: 1})
+
+	_, err = key.GenerateCode(time.Unix(59, 0))
+	mustOk(t, err)
+
+	_, err = NewHOTPFromKey(key)
+	mustEqual(t, err, ErrTypeNotValid)
+
+	key, err = ParseKeyFromURL("otpauth://hotp/alice@bob.com?counter=1&secret=" + secretSha1)
+	mustOk(t, err)
+
+	hotp, err = NewHOTPFromKey(key)
+	mustOk(t, err)
+	mustEqual(t, hotp.Issuer(), "")
+}
+
+func TestKeySteam(t *testing.T) {
+	urls := []string{
+		"otpauth://steam/Steam:alice?secret=" + secretSha1 + "&issuer=Steam",
+		"otpauth://totp/Steam:alice?secret=" + secretSha1 + "&encoder=steam",
+	}
+
+	for _, url := range urls {
+		key, err := ParseKeyFromURLStrict(url)
+		mustOk(t, err)
+		mustEqual(t, key.IsSteam(), true)
+
+		code, err := key.GenerateCode(time.Unix(59, 0))
+		mustOk(t, err)
+		mustEqual(t, code, "PV9M4")
+
+		totp, err := NewTOTPFromKey(key)
+		mustOk(t, err)
+		mustOk(t, totp.Validate("PV9M4", time.Unix(59, 0), secretSha1))
+	}
+
+	key, err := ParseKeyFromURL("otpauth://hotp/Steam:alice?secret=" + secretSha1 + "&encoder=steam")
+	mustOk(t, err)
+	mustEqual(t, key.IsSteam(), false)
+}
+
 func b32(s string) string {
 	return b32Enc([]byte(s))
 }
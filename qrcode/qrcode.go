@@ -0,0 +1,353 @@
+// Package qrcode implements a dependency-free QR code encoder
+// suitable for rendering otpauth URLs.
+package qrcode
+
+import (
+	"errors"
+)
+
+var (
+	ErrTooLong       = errors.New("qrcode: data is too long")
+	ErrLevelNotValid = errors.New("qrcode: level is not valid")
+)
+
+// Level represents the error correction level.
+type Level int
+
+const (
+	L Level = iota // Recovers 7% of data.
+	M              // Recovers 15% of data.
+	Q              // Recovers 25% of data.
+	H              // Recovers 30% of data.
+)
+
+// Code represents an encoded QR code.
+type Code struct {
+	version int
+	size    int
+	modules [][]bool
+	funcs   [][]bool
+}
+
+// Encode the text as a QR code of the smallest version in byte mode.
+func Encode(text string, level Level) (*Code, error) {
+	if level < L || level > H {
+		return nil, ErrLevelNotValid
+	}
+	data := []byte(text)
+
+	version := 1
+	for ; version <= 40; version++ {
+		if dataBits(len(data), version) <= numDataCodewords(version, level)*8 {
+			break
+		}
+	}
+	if version > 40 {
+		return nil, ErrTooLong
+	}
+
+	c := &Code{
+		version: version,
+		size:    version*4 + 17,
+	}
+	c.modules = newGrid(c.size)
+	c.funcs = newGrid(c.size)
+
+	c.drawFunctionPatterns(level)
+	c.drawCodewords(addErrorCorrection(encodeData(data, version, level), version, level))
+
+	best, minPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(level, mask)
+		if p := c.penalty(); minPenalty == -1 || p < minPenalty {
+			best, minPenalty = mask, p
+		}
+		c.applyMask(mask) // Undo, XOR is self-inverse.
+	}
+	c.applyMask(best)
+	c.drawFormatBits(level, best)
+	return c, nil
+}
+
+// Version returns the QR code version from 1 to 40.
+func (c *Code) Version() int { return c.version }
+
+// Size returns the number of modules on each side.
+func (c *Code) Size() int { return c.size }
+
+// Black reports whether the module at (x, y) is dark.
+// Coordinates outside the code are light.
+func (c *Code) Black(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.size && y < c.size && c.modules[y][x]
+}
+
+func newGrid(size int) [][]bool {
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+	return grid
+}
+
+func (c *Code) setFunc(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.funcs[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns(level Level) {
+	for i := 0; i < c.size; i++ {
+		c.setFunc(6, i, i%2 == 0)
+		c.setFunc(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.size-4, 3)
+	c.drawFinder(3, c.size-4)
+
+	pos := alignmentPositions(c.version)
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			c.drawAlignment(pos[i], pos[j])
+		}
+	}
+
+	// Reserve format area, the real bits are drawn after masking.
+	c.drawFormatBits(level, 0)
+	c.drawVersion()
+}
+
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.size || yy >= c.size {
+				continue
+			}
+			dist := maxInt(abs(dx), abs(dy))
+			c.setFunc(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunc(x+dx, y+dy, maxInt(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// formatBits are the 2-bit error correction level indicators.
+var formatBits = [4]int{L: 1, M: 0, Q: 3, H: 2}
+
+func (c *Code) drawFormatBits(level Level, mask int) {
+	data := formatBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		c.setFunc(8, i, bit(bits, i))
+	}
+	c.setFunc(8, 7, bit(bits, 6))
+	c.setFunc(8, 8, bit(bits, 7))
+	c.setFunc(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunc(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunc(c.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunc(8, c.size-15+i, bit(bits, i))
+	}
+	c.setFunc(8, c.size-8, true)
+}
+
+func (c *Code) drawVersion() {
+	if c.version < 7 {
+		return
+	}
+
+	rem := c.version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	bits := c.version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a, b := c.size-11+i%3, i/3
+		c.setFunc(a, b, bit(bits, i))
+		c.setFunc(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places data in the zigzag order skipping function modules.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.size - 1 - vert
+				}
+				if !c.funcs[y][x] && i < len(data)*8 {
+					c.modules[y][x] = bit(int(data[i>>3]), 7-(i&7))
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.funcs[y][x] {
+				continue
+			}
+
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty computes the mask penalty score as defined by ISO/IEC 18004.
+func (c *Code) penalty() int {
+	const n1, n2, n3, n4 = 3, 3, 40, 10
+
+	score, dark := 0, 0
+	for i := 0; i < c.size; i++ {
+		// Rows and columns with 5+ same colored modules in a row.
+		for _, horizontal := range []bool{true, false} {
+			at := func(j int) bool {
+				if horizontal {
+					return c.modules[i][j]
+				}
+				return c.modules[j][i]
+			}
+
+			run := 1
+			for j := 1; j < c.size; j++ {
+				if at(j) == at(j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += n1 + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				score += n1 + run - 5
+			}
+
+			// Finder-like 1:1:3:1:1 pattern with 4 light modules on either side.
+			for j := 0; j+11 <= c.size; j++ {
+				if matchFinderLike(at, j) {
+					score += n3
+				}
+			}
+		}
+	}
+
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.size && y+1 < c.size {
+				m := c.modules[y][x]
+				if m == c.modules[y][x+1] && m == c.modules[y+1][x] && m == c.modules[y+1][x+1] {
+					score += n2
+				}
+			}
+		}
+	}
+
+	total := c.size * c.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	score += k * n4
+	return score
+}
+
+var (
+	finderLikeA = [11]bool{true, false, true, true, true, false, true, false, false, false, false}
+	finderLikeB = [11]bool{false, false, false, false, true, false, true, true, true, false, true}
+)
+
+func matchFinderLike(at func(int) bool, j int) bool {
+	a, b := true, true
+	for k := 0; k < 11; k++ {
+		v := at(j + k)
+		a = a && v == finderLikeA[k]
+		b = b && v == finderLikeB[k]
+	}
+	return a || b
+}
+
+// alignmentPositions returns the centers of the alignment patterns.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	num := version/7 + 2
+	step := (version*8 + num*3 + 5) / (num*4 - 4) * 2
+	pos := make([]int, num)
+	pos[0] = 6
+	for i, p := num-1, version*4+17-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+func bit(x, i int) bool { return (x>>uint(i))&1 != 0 }
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		text    string
+		level   Level
+		version int
+	}{
+		{"", L, 1},
+		{"hello", M, 1},
+		{strings.Repeat("a", 17), L, 1},
+		{strings.Repeat("a", 18), L, 2},
+		{strings.Repeat("a", 14), M, 1},
+		{strings.Repeat("a", 15), M, 2},
+		{strings.Repeat("a", 7), H, 1},
+		{strings.Repeat("a", 8), H, 2},
+		{"otpauth://totp/cristalhq:alice@bob.com?algorithm=SHA1&digits=8&issuer=cristalhq&period=30&secret=KNCUGUSFKRPVGVCSJFHEO", M, 7},
+		{strings.Repeat("a", 2953), L, 40},
+	}
+
+	for _, tc := range testCases {
+		code, err := Encode(tc.text, tc.level)
+		mustOk(t, err)
+		mustEqual(t, code.Version(), tc.version)
+		mustEqual(t, code.Size(), tc.version*4+17)
+
+		// Finder pattern corners are dark, separators are light.
+		size := code.Size()
+		for _, p := range [][2]int{{0, 0}, {size - 1, 0}, {0, size - 1}} {
+			mustEqual(t, code.Black(p[0], p[1]), true)
+		}
+		mustEqual(t, code.Black(7, 7), false)
+		mustEqual(t, code.Black(8, size-8), true)
+		mustEqual(t, code.Black(-1, 0), false)
+		mustEqual(t, code.Black(size, 0), false)
+	}
+
+	_, err := Encode(strings.Repeat("a", 2954), L)
+	mustEqual(t, err, ErrTooLong)
+
+	_, err = Encode(strings.Repeat("a", 1274), H)
+	mustEqual(t, err, ErrTooLong)
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	code, err := Encode(strings.Repeat("a", 200), M)
+	mustOk(t, err)
+	mustEqual(t, code.Version(), 10)
+
+	// Format bits are duplicated around the top-left finder and the other two.
+	size := code.Size()
+	for i := 0; i < 8; i++ {
+		a := code.Black(8, []int{0, 1, 2, 3, 4, 5, 7, 8}[i])
+		b := code.Black(size-1-i, 8)
+		mustEqual(t, a, b)
+	}
+
+	// Version 10 information is 001010010011010011.
+	want := 0b001010010011010011
+	for i := 0; i < 18; i++ {
+		mustEqual(t, code.Black(size-11+i%3, i/3), bit(want, i))
+		mustEqual(t, code.Black(i/3, size-11+i%3), bit(want, i))
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" as 1-M, see ISO/IEC 18004 Annex I.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := rsRemainder(data, rsDivisor(10))
+	mustEqual(t, ecc, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23})
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	mustEqual(t, alignmentPositions(1), []int(nil))
+	mustEqual(t, alignmentPositions(2), []int{6, 18})
+	mustEqual(t, alignmentPositions(7), []int{6, 22, 38})
+	mustEqual(t, alignmentPositions(32), []int{6, 34, 60, 86, 112, 138})
+	mustEqual(t, alignmentPositions(40), []int{6, 30, 58, 86, 114, 142, 170})
+}
+
+func TestRender(t *testing.T) {
+	code, err := Encode("otpauth://totp/cristalhq:alice@bob.com?secret=JBSWY3DPEHPK3PXP", Q)
+	mustOk(t, err)
+	side := code.Size() + 2*quietZone
+
+	img := code.Image()
+	mustEqual(t, img.Bounds().Dx(), side*moduleSize)
+	mustEqual(t, img.Bounds().Dy(), side*moduleSize)
+
+	decoded, err := png.Decode(bytes.NewReader(code.PNG()))
+	mustOk(t, err)
+	mustEqual(t, decoded.Bounds(), img.Bounds())
+
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			r, _, _, _ := decoded.At(x*moduleSize, y*moduleSize).RGBA()
+			mustEqual(t, r == 0, code.Black(x-quietZone, y-quietZone))
+		}
+	}
+
+	svg := code.SVG()
+	mustEqual(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`), true)
+	mustEqual(t, strings.Contains(svg, `M4,4h1v1h-1z`), true)
+
+	lines := strings.Split(strings.TrimSuffix(code.String(), "\n"), "\n")
+	mustEqual(t, len(lines), (side+1)/2)
+	for _, line := range lines {
+		mustEqual(t, len([]rune(line)), side)
+	}
+	mustEqual(t, strings.Repeat("█", side), lines[0])
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustEqual(tb testing.TB, have, want interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
@@ -0,0 +1,91 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+const (
+	// quietZone is the border size in modules required by the standard.
+	quietZone = 4
+	// moduleSize is the size in pixels of a single module in Image and PNG.
+	moduleSize = 8
+)
+
+// Image returns the QR code as an image with the quiet zone,
+// each module is 8x8 pixels.
+func (c *Code) Image() image.Image {
+	side := (c.size + 2*quietZone) * moduleSize
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, side, side), palette)
+
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			if c.Black(x/moduleSize-quietZone, y/moduleSize-quietZone) {
+				img.Pix[y*img.Stride+x] = 1
+			}
+		}
+	}
+	return img
+}
+
+// PNG returns the QR code image encoded as PNG.
+func (c *Code) PNG() []byte {
+	var buf bytes.Buffer
+	// Encoding into bytes.Buffer cannot fail.
+	_ = png.Encode(&buf, c.Image())
+	return buf.Bytes()
+}
+
+// SVG returns the QR code as SVG image with the quiet zone,
+// each module is 1x1 in user units.
+func (c *Code) SVG() string {
+	side := strconv.Itoa(c.size + 2*quietZone)
+
+	var sb strings.Builder
+	sb.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 ` + side + ` ` + side + `" shape-rendering="crispEdges">`)
+	sb.WriteString(`<rect width="100%" height="100%" fill="#fff"/>`)
+	sb.WriteString(`<path fill="#000" d="`)
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			sb.WriteString("M" + strconv.Itoa(x+quietZone) + "," + strconv.Itoa(y+quietZone) + "h1v1h-1z")
+		}
+	}
+	sb.WriteString(`"/></svg>`)
+	return sb.String()
+}
+
+// String returns the QR code drawn with Unicode half blocks with the quiet zone.
+// Light modules are drawn as blocks, so the code is readable on terminals
+// with a dark background.
+func (c *Code) String() string {
+	side := c.size + 2*quietZone
+
+	var sb strings.Builder
+	for y := 0; y < side; y += 2 {
+		for x := 0; x < side; x++ {
+			top := !c.Black(x-quietZone, y-quietZone)
+			bottom := y+1 < side && !c.Black(x-quietZone, y+1-quietZone)
+
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
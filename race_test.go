@@ -0,0 +1,9 @@
+//go:build race
+// +build race
+
+package otp
+
+func init() {
+	// sync.Pool randomly drops items under the race detector.
+	raceEnabled = true
+}
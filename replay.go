@@ -0,0 +1,75 @@
+package otp
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCodeReused = errors.New("code is already used")
+
+// StepStore keeps the last accepted TOTP time step per account.
+type StepStore interface {
+	// UseStep marks the step as accepted for the account.
+	// Returns false if the step is less than or equal to the last accepted one.
+	UseStep(account string, step uint64) (bool, error)
+}
+
+// MemoryStepStore is an in-memory StepStore safe for concurrent use.
+type MemoryStepStore struct {
+	mu    sync.Mutex
+	steps map[string]uint64
+}
+
+// NewMemoryStepStore creates new MemoryStepStore.
+func NewMemoryStepStore() *MemoryStepStore {
+	return &MemoryStepStore{
+		steps: map[string]uint64{},
+	}
+}
+
+// UseStep implements StepStore.
+func (s *MemoryStepStore) UseStep(account string, step uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.steps[account]; ok && step <= last {
+		return false, nil
+	}
+	s.steps[account] = step
+	return true, nil
+}
+
+// ReplayValidator validates TOTP codes and rejects already used ones.
+// See: https://datatracker.ietf.org/doc/html/rfc6238#section-5.2
+type ReplayValidator struct {
+	totp  *TOTP
+	store StepStore
+}
+
+// NewReplayValidator creates new ReplayValidator.
+func NewReplayValidator(totp *TOTP, store StepStore) *ReplayValidator {
+	return &ReplayValidator{
+		totp:  totp,
+		store: store,
+	}
+}
+
+// Validate the given passcode, time and secret for the account.
+// Returns ErrCodeReused if the matched time step is not after the last accepted one.
+func (v *ReplayValidator) Validate(account, passcode string, at time.Time, secret string) (TOTPResult, error) {
+	res, err := v.totp.ValidateResult(passcode, at, secret)
+	if err != nil {
+		return TOTPResult{}, err
+	}
+
+	ok, err := v.store.UseStep(account, res.Counter)
+	switch {
+	case err != nil:
+		return TOTPResult{}, err
+	case !ok:
+		return TOTPResult{}, ErrCodeReused
+	default:
+		return res, nil
+	}
+}
@@ -0,0 +1,69 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplayValidator(t *testing.T) {
+	totp, err := NewTOTP(TOTPConfig{
+		Algo:   AlgorithmSHA1,
+		Digits: 8,
+		Issuer: "cristalhq",
+		Period: 30,
+		Skew:   1,
+	})
+	mustOk(t, err)
+
+	v := NewReplayValidator(totp, NewMemoryStepStore())
+	at := time.Unix(1111111111, 0)
+
+	res, err := v.Validate("alice", "14050471", at, secretSha1)
+	mustOk(t, err)
+	mustEqual(t, res.Counter, uint64(37037037))
+
+	_, err = v.Validate("alice", "14050471", at, secretSha1)
+	mustEqual(t, err, ErrCodeReused)
+
+	_, err = v.Validate("alice", "14050471", at.Add(30*time.Second), secretSha1)
+	mustEqual(t, err, ErrCodeReused)
+
+	// Code for the previous step is rejected after a newer one was accepted.
+	prev, err := totp.GenerateCode(secretSha1, at.Add(-30*time.Second))
+	mustOk(t, err)
+	_, err = v.Validate("alice", prev, at, secretSha1)
+	mustEqual(t, err, ErrCodeReused)
+
+	next, err := totp.GenerateCode(secretSha1, at.Add(30*time.Second))
+	mustOk(t, err)
+	res, err = v.Validate("alice", next, at.Add(30*time.Second), secretSha1)
+	mustOk(t, err)
+	mustEqual(t, res.Counter, uint64(37037038))
+
+	// Accounts are independent.
+	_, err = v.Validate("bob", "14050471", at, secretSha1)
+	mustOk(t, err)
+
+	_, err = v.Validate("carol", "00000000", at, secretSha1)
+	mustEqual(t, err, ErrCodeIsNotValid)
+}
+
+func TestMemoryStepStore(t *testing.T) {
+	s := NewMemoryStepStore()
+
+	ok, err := s.UseStep("alice", 0)
+	mustOk(t, err)
+	mustEqual(t, ok, true)
+
+	ok, err = s.UseStep("alice", 0)
+	mustOk(t, err)
+	mustEqual(t, ok, false)
+
+	ok, err = s.UseStep("alice", 2)
+	mustOk(t, err)
+	mustEqual(t, ok, true)
+
+	ok, err = s.UseStep("alice", 1)
+	mustOk(t, err)
+	mustEqual(t, ok, false)
+}
@@ -0,0 +1,272 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+var ErrSecretTooShort = errors.New("secret is too short")
+
+// SecretEncoding represents the text encoding of a secret.
+type SecretEncoding int
+
+const (
+	// SecretBase32 is RFC 4648 base32, case-insensitive with optional padding.
+	SecretBase32 SecretEncoding = iota
+	// SecretHex is case-insensitive hexadecimal.
+	SecretHex
+	// SecretBase64 is standard or URL-safe base64 with optional padding.
+	SecretBase64
+	// SecretRaw uses the string bytes as is.
+	SecretRaw
+
+	secretEncodingMax
+)
+
+func (e SecretEncoding) String() string {
+	switch e {
+	case SecretBase32:
+		return "base32"
+	case SecretHex:
+		return "hex"
+	case SecretBase64:
+		return "base64"
+	case SecretRaw:
+		return "raw"
+	default:
+		return "unknown"
+	}
+}
+
+// SecretCharError reports an invalid character in the encoded secret.
+// It matches ErrEncodingNotValid with errors.Is.
+type SecretCharError struct {
+	Encoding SecretEncoding
+	Char     rune
+	// Offset is the byte offset of the character in the secret.
+	Offset int
+	// AfterPadding is set when the character is valid but follows padding.
+	AfterPadding bool
+}
+
+func (e *SecretCharError) Error() string {
+	if e.AfterPadding {
+		return fmt.Sprintf("otp: %s character %q at offset %d in secret follows padding", e.Encoding, e.Char, e.Offset)
+	}
+	return fmt.Sprintf("otp: invalid %s character %q at offset %d in secret", e.Encoding, e.Char, e.Offset)
+}
+
+func (e *SecretCharError) Is(err error) bool { return err == ErrEncodingNotValid }
+
+// minSecretSize is 128 bits, see: https://datatracker.ietf.org/doc/html/rfc4226#section-4 (R6)
+const minSecretSize = 16
+
+// GenerateSecret returns a random secret and its base32 form.
+// Zero size means the size of the algorithm hash output (20 bytes for SHA1,
+// 32 for SHA256 and 64 for SHA512). Nil r means crypto/rand.Reader.
+func GenerateSecret(algo Algorithm, size int, r io.Reader) ([]byte, string, error) {
+	if algo == 0 || algo >= algorithmMax {
+		return nil, "", ErrUnsupportedAlgorithm
+	}
+	if size == 0 {
+		size = algo.Hash().Size()
+	}
+	if size < minSecretSize {
+		return nil, "", ErrSecretTooShort
+	}
+	if r == nil {
+		r = rand.Reader
+	}
+
+	secret := make([]byte, size)
+	if _, err := io.ReadFull(r, secret); err != nil {
+		return nil, "", err
+	}
+	return secret, b32Enc(secret), nil
+}
+
+// Secret represents a decoded secret with reusable keyed HMAC states.
+// Decode the secret once and pass it to the *Secret methods of HOTP and TOTP
+// to avoid decoding and HMAC key setup on each call.
+//
+// Secret is safe for concurrent use.
+type Secret struct {
+	key []byte
+	// macs are the keyed HMAC states for each algorithm.
+	macs [algorithmMax]sync.Pool
+}
+
+// NewSecret creates new Secret from the raw bytes, the bytes are copied.
+func NewSecret(raw []byte) *Secret {
+	key := make([]byte, len(raw))
+	copy(key, raw)
+	return &Secret{key: key}
+}
+
+// NewSecretFromString creates new Secret from the string in the given encoding.
+// Whitespace is ignored, so are hyphens except in base64.
+// Returns *SecretCharError for an invalid character and ErrEncodingNotValid
+// for other decoding errors, both match ErrEncodingNotValid with errors.Is.
+func NewSecretFromString(s string, enc SecretEncoding) (*Secret, error) {
+	key, err := decodeSecret(s, enc)
+	if err != nil {
+		return nil, err
+	}
+	return &Secret{key: key}, nil
+}
+
+// NewSecretFromBase32 creates new Secret from base32 string.
+// See NewSecretFromString for the accepted input.
+func NewSecretFromBase32(s string) (*Secret, error) {
+	return NewSecretFromString(s, SecretBase32)
+}
+
+// NewSecretFromHex creates new Secret from hex string.
+// See NewSecretFromString for the accepted input.
+func NewSecretFromHex(s string) (*Secret, error) {
+	return NewSecretFromString(s, SecretHex)
+}
+
+// Bytes returns a copy of the raw secret.
+func (s *Secret) Bytes() []byte {
+	b := make([]byte, len(s.key))
+	copy(b, s.key)
+	return b
+}
+
+// Base32 returns the secret as unpadded base32 string.
+func (s *Secret) Base32() string { return b32Enc(s.key) }
+
+// macState is a keyed HMAC with scratch buffers reused between calls.
+type macState struct {
+	mac  hash.Hash
+	buf  [8]byte
+	out  [sha512.Size]byte
+	code []byte
+	// used is set after the first sum, a fresh HMAC needs no reset.
+	used bool
+}
+
+// state returns the HMAC state for the algorithm, release it after use.
+func (s *Secret) state(algo Algorithm) *macState {
+	if st, ok := s.macs[algo].Get().(*macState); ok {
+		return st
+	}
+	return &macState{mac: hmac.New(algo.Hash, s.key)}
+}
+
+func (s *Secret) release(algo Algorithm, st *macState) {
+	s.macs[algo].Put(st)
+}
+
+// sum returns HMAC of the big-endian counter.
+// The result is valid until the next call.
+func (st *macState) sum(counter uint64) []byte {
+	if st.used {
+		st.mac.Reset()
+	}
+	st.used = true
+	binary.BigEndian.PutUint64(st.buf[:], counter)
+	st.mac.Write(st.buf[:])
+	return st.mac.Sum(st.out[:0])
+}
+
+// decodeSecret normalizes and decodes the secret, see NewSecretFromString.
+func decodeSecret(s string, enc SecretEncoding) ([]byte, error) {
+	if enc == SecretRaw {
+		return []byte(s), nil
+	}
+	if enc < 0 || enc >= secretEncodingMax {
+		return nil, ErrEncodingNotValid
+	}
+
+	buf := make([]byte, 0, len(s))
+	padding := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		c, ok := secretChar(r, enc)
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+		case r == '-' && enc != SecretBase64:
+		case r == '=' && enc != SecretHex:
+			padding = true
+		case !ok || padding:
+			return nil, &SecretCharError{Encoding: enc, Char: r, Offset: i, AfterPadding: ok}
+		default:
+			buf = append(buf, c)
+		}
+		i += size
+	}
+
+	var key []byte
+	var err error
+	switch enc {
+	case SecretBase32:
+		// Unpadded decoding does not check the length of the last quantum.
+		switch len(buf) % 8 {
+		case 1, 3, 6:
+			return nil, ErrEncodingNotValid
+		}
+		b32 := base32.StdEncoding.WithPadding(base32.NoPadding)
+		key = make([]byte, b32.DecodedLen(len(buf)))
+		var n int
+		n, err = b32.Decode(key, buf)
+		key = key[:n]
+	case SecretHex:
+		key = make([]byte, hex.DecodedLen(len(buf)))
+		_, err = hex.Decode(key, buf)
+	case SecretBase64:
+		key = make([]byte, base64.RawStdEncoding.DecodedLen(len(buf)))
+		_, err = base64.RawStdEncoding.Decode(key, buf)
+	}
+	if err != nil {
+		return nil, ErrEncodingNotValid
+	}
+	return key, nil
+}
+
+// secretChar returns the character in the canonical alphabet of the encoding
+// and reports whether it belongs to the encoding.
+func secretChar(r rune, enc SecretEncoding) (byte, bool) {
+	if r >= utf8.RuneSelf {
+		return 0, false
+	}
+	c := byte(r)
+	isUpper, isLower, isDigit := 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9'
+
+	switch enc {
+	case SecretBase32:
+		switch {
+		case isUpper, '2' <= c && c <= '7':
+			return c, true
+		case isLower:
+			return c - 'a' + 'A', true
+		}
+	case SecretHex:
+		if isDigit || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F' {
+			return c, true
+		}
+	case SecretBase64:
+		switch {
+		case isUpper, isLower, isDigit, c == '+', c == '/':
+			return c, true
+		case c == '-':
+			return '+', true
+		case c == '_':
+			return '/', true
+		}
+	}
+	return 0, false
+}
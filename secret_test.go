@@ -0,0 +1,294 @@
+package otp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	testCases := []struct {
+		algo Algorithm
+		size int
+		want int
+	}{
+		{AlgorithmSHA1, 0, 20},
+		{AlgorithmSHA256, 0, 32},
+		{AlgorithmSHA512, 0, 64},
+		{AlgorithmSHA1, 16, 16},
+		{AlgorithmSHA1, 40, 40},
+	}
+
+	for _, tc := range testCases {
+		raw, encoded, err := GenerateSecret(tc.algo, tc.size, nil)
+		mustOk(t, err)
+		mustEqual(t, len(raw), tc.want)
+		mustEqual(t, encoded, b32Enc(raw))
+	}
+
+	r := bytes.NewReader([]byte("12345678901234567890"))
+	raw, encoded, err := GenerateSecret(AlgorithmSHA1, 0, r)
+	mustOk(t, err)
+	mustEqual(t, raw, []byte("12345678901234567890"))
+	mustEqual(t, encoded, secretSha1)
+
+	_, _, err = GenerateSecret(AlgorithmUnknown, 0, nil)
+	mustEqual(t, err, ErrUnsupportedAlgorithm)
+
+	_, _, err = GenerateSecret(AlgorithmSHA1, 10, nil)
+	mustEqual(t, err, ErrSecretTooShort)
+
+	_, _, err = GenerateSecret(AlgorithmSHA1, 0, bytes.NewReader([]byte("short")))
+	mustEqual(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestSecret(t *testing.T) {
+	raw := []byte("12345678901234567890")
+
+	s1 := NewSecret(raw)
+	s2, err := NewSecretFromBase32(secretSha1)
+	mustOk(t, err)
+	s3, err := NewSecretFromHex("3132333435363738393031323334353637383930")
+	mustOk(t, err)
+
+	for _, s := range []*Secret{s1, s2, s3} {
+		mustEqual(t, s.Bytes(), raw)
+		mustEqual(t, s.Base32(), secretSha1)
+	}
+
+	_, err = NewSecretFromBase32("not base32!")
+	mustEqual(t, err, error(&SecretCharError{Encoding: SecretBase32, Char: '!', Offset: 10}))
+	mustEqual(t, errors.Is(err, ErrEncodingNotValid), true)
+
+	_, err = NewSecretFromHex("xyz")
+	mustEqual(t, errors.Is(err, ErrEncodingNotValid), true)
+}
+
+func TestSecretEncoding(t *testing.T) {
+	raw := []byte("12345678901234567890")
+
+	testCases := []struct {
+		s   string
+		enc SecretEncoding
+	}{
+		{"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", SecretBase32},
+		{"gezdgnbvgy3tqojqgezdgnbvgy3tqojq", SecretBase32},
+		{"gezd gnbv gy3t qojq gezd gnbv gy3t qojq", SecretBase32},
+		{"GEZD-GNBV-GY3T-QOJQ-GEZD-GNBV-GY3T-QOJQ", SecretBase32},
+		{"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\n", SecretBase32},
+		{"3132333435363738393031323334353637383930", SecretHex},
+		{"31 32 33 34 35 36 37 38 39 30 31 32 33 34 35 36 37 38 39 30", SecretHex},
+		{"31-32-33-34-35-36-37-38-39-30-31-32-33-34-35-36-37-38-39-30", SecretHex},
+		{"MTIzNDU2Nzg5MDEyMzQ1Njc4OTA=", SecretBase64},
+		{"MTIzNDU2Nzg5MDEyMzQ1Njc4OTA", SecretBase64},
+		{"12345678901234567890", SecretRaw},
+	}
+
+	for _, tc := range testCases {
+		s, err := NewSecretFromString(tc.s, tc.enc)
+		mustOk(t, err)
+		mustEqual(t, s.Bytes(), raw)
+	}
+
+	// Padding is optional and the padded form is accepted too.
+	s, err := NewSecretFromBase32("JBSWY3DPEE======")
+	mustOk(t, err)
+	mustEqual(t, s.Bytes(), []byte("Hello!"))
+
+	// URL-safe base64.
+	s, err = NewSecretFromString("-_8", SecretBase64)
+	mustOk(t, err)
+	mustEqual(t, s.Bytes(), []byte{0xfb, 0xff})
+
+	errCases := []struct {
+		s   string
+		enc SecretEncoding
+		err error
+	}{
+		{"JBSWY3DPEHPK3PX1", SecretBase32, &SecretCharError{SecretBase32, '1', 15, false}},
+		{"JBSWY3DP É", SecretBase32, &SecretCharError{SecretBase32, 'É', 9, false}},
+		{"JBSWY3DPEE==A", SecretBase32, &SecretCharError{SecretBase32, 'A', 12, true}},
+		{"31323g", SecretHex, &SecretCharError{SecretHex, 'g', 5, false}},
+		{"3132=", SecretHex, &SecretCharError{SecretHex, '=', 4, false}},
+		{"MTIz!", SecretBase64, &SecretCharError{SecretBase64, '!', 4, false}},
+		{"JBS", SecretBase32, ErrEncodingNotValid},
+		{"313", SecretHex, ErrEncodingNotValid},
+		{"M", SecretBase64, ErrEncodingNotValid},
+		{"JBSWY3DP", SecretEncoding(42), ErrEncodingNotValid},
+	}
+
+	for _, tc := range errCases {
+		_, err := NewSecretFromString(tc.s, tc.enc)
+		mustEqual(t, err, tc.err)
+		mustEqual(t, errors.Is(err, ErrEncodingNotValid), true)
+	}
+
+	_, err = NewSecretFromString("JBSWY3DPEE==A", SecretBase32)
+	mustEqual(t, err.Error(), `otp: base32 character 'A' at offset 12 in secret follows padding`)
+
+	hotp, err := NewHOTP(HOTPConfig{
+		Algo:           AlgorithmSHA1,
+		Digits:         6,
+		Issuer:         "cristalhq",
+		SecretEncoding: SecretHex,
+	})
+	mustOk(t, err)
+
+	code, err := hotp.GenerateCode(1, "3132333435363738393031323334353637383930")
+	mustOk(t, err)
+	mustEqual(t, code, "287082")
+
+	totp, err := NewTOTP(TOTPConfig{
+		Algo:           AlgorithmSHA1,
+		Digits:         8,
+		Issuer:         "cristalhq",
+		Period:         30,
+		SecretEncoding: SecretRaw,
+	})
+	mustOk(t, err)
+
+	err = totp.Validate("94287082", time.Unix(59, 0), "12345678901234567890")
+	mustOk(t, err)
+
+	_, err = NewHOTP(HOTPConfig{
+		Algo:           AlgorithmSHA1,
+		Digits:         6,
+		Issuer:         "cristalhq",
+		SecretEncoding: SecretEncoding(42),
+	})
+	mustEqual(t, err, ErrEncodingNotValid)
+}
+
+func TestSecretCodes(t *testing.T) {
+	testCases := []struct {
+		algo   Algorithm
+		secret string
+		code   string
+	}{
+		{AlgorithmSHA1, secretSha1, "07081804"},
+		{AlgorithmSHA256, secretSha256, "68084774"},
+		{AlgorithmSHA512, secretSha512, "25091201"},
+	}
+
+	at := time.Unix(1111111109, 0)
+	for _, tc := range testCases {
+		totp, err := NewTOTP(TOTPConfig{
+			Algo:   tc.algo,
+			Digits: 8,
+			Issuer: "cristalhq",
+			Period: 30,
+			Skew:   1,
+		})
+		mustOk(t, err)
+
+		secret, err := NewSecretFromBase32(tc.secret)
+		mustOk(t, err)
+
+		// Concurrent calls share the pooled HMAC states.
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					code, err := totp.GenerateCodeSecret(secret, at)
+					if err != nil || code != tc.code {
+						t.Errorf("have %q, want %q", code, tc.code)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		res, err := totp.ValidateResultSecret(tc.code, at.Add(30*time.Second), secret)
+		mustOk(t, err)
+		mustEqual(t, res.Offset, -1)
+
+		err = totp.ValidateSecret("00000000", at, secret)
+		mustEqual(t, err, ErrCodeIsNotValid)
+	}
+
+	hotp, err := NewHOTP(HOTPConfig{
+		Algo:      AlgorithmSHA1,
+		Digits:    6,
+		Issuer:    "cristalhq",
+		LookAhead: 3,
+	})
+	mustOk(t, err)
+
+	secret := NewSecret([]byte("12345678901234567890"))
+	mustEqual(t, hotp.GenerateCodeSecret(1, secret), "287082")
+	mustOk(t, hotp.ValidateSecret("287082", 1, secret))
+
+	counter, err := hotp.ValidateCounterSecret("969429", 1, secret)
+	mustOk(t, err)
+	mustEqual(t, counter, uint64(3))
+}
+
+var raceEnabled bool
+
+func TestSecretAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable with the race detector")
+	}
+
+	testCases := []struct {
+		algo   Algorithm
+		secret string
+	}{
+		{AlgorithmSHA1, secretSha1},
+		{AlgorithmSHA256, secretSha256},
+		{AlgorithmSHA512, secretSha512},
+	}
+
+	at := time.Unix(1111111111, 0)
+	for _, tc := range testCases {
+		hotp, err := NewHOTP(HOTPConfig{
+			Algo:   tc.algo,
+			Digits: 8,
+			Issuer: "cristalhq",
+		})
+		mustOk(t, err)
+
+		totp, err := NewTOTP(TOTPConfig{
+			Algo:   tc.algo,
+			Digits: 8,
+			Issuer: "cristalhq",
+			Period: 30,
+			Skew:   1,
+		})
+		mustOk(t, err)
+
+		secret, err := NewSecretFromBase32(tc.secret)
+		mustOk(t, err)
+		passcode, err := totp.GenerateCodeSecret(secret, at)
+		mustOk(t, err)
+
+		buf := make([]byte, 0, 8)
+		allocs := testing.AllocsPerRun(100, func() {
+			buf = hotp.AppendCode(buf[:0], 42, secret)
+		})
+		mustEqual(t, allocs, 0.0)
+
+		allocs = testing.AllocsPerRun(100, func() {
+			buf, _ = totp.AppendCode(buf[:0], secret, at)
+		})
+		mustEqual(t, allocs, 0.0)
+		mustEqual(t, string(buf), passcode)
+
+		allocs = testing.AllocsPerRun(100, func() {
+			_ = hotp.ValidateSecret(passcode, 42, secret)
+		})
+		mustEqual(t, allocs, 0.0)
+
+		allocs = testing.AllocsPerRun(100, func() {
+			// Miss the current step to check the whole window.
+			_, _ = totp.ValidateResultSecret(passcode, at.Add(time.Hour), secret)
+		})
+		mustEqual(t, allocs, 0.0)
+	}
+}
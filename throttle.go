@@ -0,0 +1,221 @@
+package otp
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTooManyAttempts     = errors.New("too many attempts")
+	ErrMaxAttemptsNotValid = errors.New("max attempts is not valid")
+	ErrDelayNotValid       = errors.New("delay is not valid")
+)
+
+// ThrottleError is returned when validation is throttled.
+// It matches ErrTooManyAttempts with errors.Is.
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return "otp: too many attempts, retry after " + e.RetryAfter.String()
+}
+
+func (e *ThrottleError) Is(err error) bool { return err == ErrTooManyAttempts }
+
+// ResetError is returned when the validation succeeded
+// but the store failed to reset the failures. It wraps the store error.
+type ResetError struct {
+	Err error
+}
+
+func (e *ResetError) Error() string {
+	return "otp: code is valid, but failures are not reset: " + e.Err.Error()
+}
+
+func (e *ResetError) Unwrap() error { return e.Err }
+
+// AttemptStore keeps failed validation attempts per account.
+type AttemptStore interface {
+	// Begin reserves an attempt for the account at the given time.
+	// It calls allow with the number of failures and the time of the last one
+	// and, if allow returns true, records the attempt as a failure.
+	// The check and the record must be atomic, so concurrent attempts
+	// cannot pass the limit. Returns the result of allow.
+	Begin(account string, at time.Time, allow func(count uint, last time.Time) bool) (bool, error)
+	// Cancel removes an attempt recorded by Begin that turned out not to be
+	// a failure, the time of the last failure may be kept.
+	Cancel(account string) error
+	// Reset removes all the failures for the account.
+	Reset(account string) error
+}
+
+// MemoryAttemptStore is an in-memory AttemptStore safe for concurrent use.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]attempts
+}
+
+type attempts struct {
+	count uint
+	last  time.Time
+}
+
+// NewMemoryAttemptStore creates new MemoryAttemptStore.
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		attempts: map[string]attempts{},
+	}
+}
+
+// Begin implements AttemptStore.
+func (s *MemoryAttemptStore) Begin(account string, at time.Time, allow func(count uint, last time.Time) bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.attempts[account]
+	if !allow(a.count, a.last) {
+		return false, nil
+	}
+	a.count++
+	a.last = at
+	s.attempts[account] = a
+	return true, nil
+}
+
+// Cancel implements AttemptStore.
+func (s *MemoryAttemptStore) Cancel(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attempts[account]
+	switch {
+	case !ok:
+	case a.count <= 1:
+		delete(s.attempts, account)
+	default:
+		a.count--
+		s.attempts[account] = a
+	}
+	return nil
+}
+
+// Reset implements AttemptStore.
+func (s *MemoryAttemptStore) Reset(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, account)
+	return nil
+}
+
+// Throttler limits validation attempts per account.
+// See: https://datatracker.ietf.org/doc/html/rfc4226#section-7.3
+type Throttler struct {
+	cfg   ThrottlerConfig
+	store AttemptStore
+}
+
+type ThrottlerConfig struct {
+	// MaxAttempts is the number of failures allowed without a delay.
+	MaxAttempts uint
+	// Delay is the delay after MaxAttempts failures, it doubles on each next failure.
+	// Zero Delay means a hard lockout after MaxAttempts failures until Unlock.
+	Delay time.Duration
+	// MaxDelay limits the exponential delay, zero means no limit.
+	MaxDelay time.Duration
+}
+
+func (cfg ThrottlerConfig) Validate() error {
+	switch {
+	case cfg.MaxAttempts == 0:
+		return ErrMaxAttemptsNotValid
+	case cfg.Delay < 0 || cfg.MaxDelay < 0:
+		return ErrDelayNotValid
+	default:
+		return nil
+	}
+}
+
+// NewThrottler creates new Throttler.
+func NewThrottler(cfg ThrottlerConfig, store AttemptStore) (*Throttler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Throttler{
+		cfg:   cfg,
+		store: store,
+	}, nil
+}
+
+// Validate calls fn if the account is not throttled at the given time.
+// The attempt is recorded as a failure before fn is called and kept only
+// when fn returns ErrCodeIsNotValid or ErrCodeLengthMismatch,
+// success of fn resets the failures.
+// Returns *ThrottleError (matching ErrTooManyAttempts) when throttled
+// and *ResetError when fn succeeded but the failures are not reset.
+//
+//	err := throttler.Validate(account, now, func() error {
+//		return totp.Validate(passcode, now, secret)
+//	})
+func (t *Throttler) Validate(account string, at time.Time, fn func() error) error {
+	var retry time.Duration
+	ok, err := t.store.Begin(account, at, func(count uint, last time.Time) bool {
+		var throttled bool
+		retry, throttled = t.retryAfter(count, last, at)
+		return !throttled
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &ThrottleError{RetryAfter: retry}
+	}
+
+	switch err := fn(); {
+	case err == nil:
+		if err := t.store.Reset(account); err != nil {
+			return &ResetError{Err: err}
+		}
+		return nil
+	case errors.Is(err, ErrCodeIsNotValid), errors.Is(err, ErrCodeLengthMismatch):
+		return err
+	default:
+		// Not a wrong code, like a secret decoding error. The error of fn
+		// is more useful, a Cancel error only leaves the attempt counted.
+		_ = t.store.Cancel(account)
+		return err
+	}
+}
+
+// Unlock removes all the failures for the account.
+func (t *Throttler) Unlock(account string) error {
+	return t.store.Reset(account)
+}
+
+// retryAfter reports whether the account is throttled and for how long.
+func (t *Throttler) retryAfter(count uint, last, at time.Time) (time.Duration, bool) {
+	if count < t.cfg.MaxAttempts {
+		return 0, false
+	}
+	if t.cfg.Delay == 0 {
+		return time.Duration(1<<63 - 1), true
+	}
+
+	delay := t.cfg.Delay
+	for i := t.cfg.MaxAttempts; i < count; i++ {
+		if t.cfg.MaxDelay != 0 && delay >= t.cfg.MaxDelay || delay > 1<<62 {
+			break
+		}
+		delay *= 2
+	}
+	if t.cfg.MaxDelay != 0 && delay > t.cfg.MaxDelay {
+		delay = t.cfg.MaxDelay
+	}
+
+	if wait := last.Add(delay).Sub(at); wait > 0 {
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package otp
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottlerDelay(t *testing.T) {
+	throttler, err := NewThrottler(ThrottlerConfig{
+		MaxAttempts: 2,
+		Delay:       time.Second,
+		MaxDelay:    4 * time.Second,
+	}, NewMemoryAttemptStore())
+	mustOk(t, err)
+
+	fail := func() error { return ErrCodeIsNotValid }
+	pass := func() error { return nil }
+	at := time.Unix(1000, 0)
+
+	mustEqual(t, throttler.Validate("alice", at, fail), ErrCodeIsNotValid)
+	mustEqual(t, throttler.Validate("alice", at, fail), ErrCodeIsNotValid)
+
+	err = throttler.Validate("alice", at, pass)
+	mustEqual(t, errors.Is(err, ErrTooManyAttempts), true)
+	mustEqual(t, err.(*ThrottleError).RetryAfter, time.Second)
+
+	at = at.Add(time.Second)
+	mustEqual(t, throttler.Validate("alice", at, fail), ErrCodeIsNotValid)
+
+	err = throttler.Validate("alice", at.Add(time.Second), pass)
+	mustEqual(t, err.(*ThrottleError).RetryAfter, time.Second)
+
+	at = at.Add(2 * time.Second)
+	mustEqual(t, throttler.Validate("alice", at, fail), ErrCodeIsNotValid)
+	at = at.Add(4 * time.Second)
+	mustEqual(t, throttler.Validate("alice", at, fail), ErrCodeIsNotValid)
+
+	// Delay is limited by MaxDelay.
+	err = throttler.Validate("alice", at, pass)
+	mustEqual(t, err.(*ThrottleError).RetryAfter, 4*time.Second)
+
+	// Other accounts are not affected.
+	mustOk(t, throttler.Validate("bob", at, pass))
+
+	// Success resets the failures.
+	at = at.Add(4 * time.Second)
+	mustOk(t, throttler.Validate("alice", at, pass))
+	mustEqual(t, throttler.Validate("alice", at, fail), ErrCodeIsNotValid)
+	mustEqual(t, throttler.Validate("alice", at, fail), ErrCodeIsNotValid)
+	mustOk(t, throttler.Validate("alice", at.Add(time.Second), pass))
+}
+
+func TestThrottlerLockout(t *testing.T) {
+	throttler, err := NewThrottler(ThrottlerConfig{
+		MaxAttempts: 1,
+	}, NewMemoryAttemptStore())
+	mustOk(t, err)
+
+	at := time.Unix(1000, 0)
+	mustEqual(t, throttler.Validate("alice", at, func() error { return ErrCodeIsNotValid }), ErrCodeIsNotValid)
+
+	err = throttler.Validate("alice", at.Add(24*time.Hour), func() error { return nil })
+	mustEqual(t, errors.Is(err, ErrTooManyAttempts), true)
+
+	mustOk(t, throttler.Unlock("alice"))
+	mustOk(t, throttler.Validate("alice", at, func() error { return nil }))
+}
+
+func TestThrottlerConcurrent(t *testing.T) {
+	throttler, err := NewThrottler(ThrottlerConfig{
+		MaxAttempts: 3,
+	}, NewMemoryAttemptStore())
+	mustOk(t, err)
+
+	var calls int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	at := time.Unix(1000, 0)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_ = throttler.Validate("alice", at, func() error {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(time.Millisecond)
+				return ErrCodeIsNotValid
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	mustEqual(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestThrottlerOtherErrors(t *testing.T) {
+	throttler, err := NewThrottler(ThrottlerConfig{
+		MaxAttempts: 1,
+	}, NewMemoryAttemptStore())
+	mustOk(t, err)
+
+	at := time.Unix(1000, 0)
+	for i := 0; i < 3; i++ {
+		err = throttler.Validate("alice", at, func() error { return ErrEncodingNotValid })
+		mustEqual(t, err, ErrEncodingNotValid)
+	}
+	mustOk(t, throttler.Validate("alice", at, func() error { return nil }))
+
+	mustEqual(t, throttler.Validate("alice", at, func() error { return ErrCodeLengthMismatch }), ErrCodeLengthMismatch)
+	err = throttler.Validate("alice", at, func() error { return nil })
+	mustEqual(t, errors.Is(err, ErrTooManyAttempts), true)
+}
+
+type resetErrorStore struct {
+	*MemoryAttemptStore
+}
+
+var errStore = errors.New("store is down")
+
+func (resetErrorStore) Reset(string) error { return errStore }
+
+func TestThrottlerResetError(t *testing.T) {
+	throttler, err := NewThrottler(ThrottlerConfig{
+		MaxAttempts: 1,
+	}, resetErrorStore{NewMemoryAttemptStore()})
+	mustOk(t, err)
+
+	err = throttler.Validate("alice", time.Unix(1000, 0), func() error { return nil })
+	var resetErr *ResetError
+	mustEqual(t, errors.As(err, &resetErr), true)
+	mustEqual(t, errors.Is(err, errStore), true)
+}
+
+func TestNewThrottler(t *testing.T) {
+	_, err := NewThrottler(ThrottlerConfig{MaxAttempts: 0}, NewMemoryAttemptStore())
+	mustEqual(t, err, ErrMaxAttemptsNotValid)
+
+	_, err = NewThrottler(ThrottlerConfig{MaxAttempts: 3, Delay: -1}, NewMemoryAttemptStore())
+	mustEqual(t, err, ErrDelayNotValid)
+}
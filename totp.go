@@ -2,7 +2,7 @@ package otp
 
 import (
 	"math"
-	"net/url"
+	"math/bits"
 	"time"
 )
 
@@ -10,6 +10,10 @@ import (
 type TOTP struct {
 	hotp HOTP
 	cfg  TOTPConfig
+	step time.Duration
+
+	skewBackward uint64
+	skewForward  uint64
 }
 
 type TOTPConfig struct {
@@ -17,21 +21,66 @@ type TOTPConfig struct {
 	Digits uint
 	Issuer string
 	Period uint64
-	Skew   uint
+
+	// Skew is the number of time steps accepted both before and after
+	// the current one. Cannot be used with SkewBackward and SkewForward.
+	Skew uint
+
+	// SkewBackward is the number of past time steps accepted to tolerate
+	// network latency and clock drift.
+	SkewBackward uint
+
+	// SkewForward is the number of future time steps accepted.
+	SkewForward uint
+
+	// Interval is the time step as a duration, used instead of Period
+	// for sub-second or non-integer periods. Only one of them can be set.
+	Interval time.Duration
+
+	// T0 is the time to start counting time steps from, Unix epoch if zero.
+	// See: https://datatracker.ietf.org/doc/html/rfc6238#section-4.1
+	T0 time.Time
+
+	// Encoder for the codes of Digits length, EncoderDecimal if nil.
+	// Marshaled to JSON by name, see EncoderByName.
+	Encoder Encoder `json:"-"`
+
+	// LongCodes enables extended truncation, see HOTPConfig.LongCodes.
+	LongCodes bool
+
+	// SecretEncoding of the string secrets, base32 by default.
+	SecretEncoding SecretEncoding
+
+	// Clock used by Code and Verify, system clock if nil.
+	// Not marshaled to JSON.
+	Clock Clock `json:"-"`
 }
 
 func (cfg TOTPConfig) Validate() error {
+	return cfg.validate(true)
+}
+
+// validate checks the config, the issuer is required only when needIssuer.
+func (cfg TOTPConfig) validate(needIssuer bool) error {
 	switch {
 	case cfg.Algo == 0 || cfg.Algo >= algorithmMax:
 		return ErrUnsupportedAlgorithm
 	case cfg.Digits == 0:
 		return ErrNoDigits
-	case cfg.Issuer == "":
+	case !validEncoder(cfg.Encoder):
+		return ErrEncoderNotValid
+	case !validDigits(cfg.Digits, cfg.Encoder, cfg.LongCodes):
+		return ErrDigitsNotValid
+	case needIssuer && cfg.Issuer == "":
 		return ErrEmptyIssuer
-	case cfg.Period == 0:
+	case cfg.Period == 0 && cfg.Interval <= 0,
+		cfg.Period != 0 && cfg.Interval != 0,
+		cfg.Period > math.MaxInt64/uint64(time.Second):
 		return ErrPeriodNotValid
-	case cfg.Skew == 0:
+	case cfg.Skew != 0 && (cfg.SkewBackward != 0 || cfg.SkewForward != 0):
 		return ErrSkewNotValid
+	case cfg.SecretEncoding < 0 || cfg.SecretEncoding >= secretEncodingMax:
+		return ErrEncodingNotValid
 	default:
 		return nil
 	}
@@ -39,45 +88,136 @@ func (cfg TOTPConfig) Validate() error {
 
 // NewTOTP creates new TOTP.
 func NewTOTP(cfg TOTPConfig) (*TOTP, error) {
-	if err := cfg.Validate(); err != nil {
+	return newTOTP(cfg, true)
+}
+
+func newTOTP(cfg TOTPConfig, needIssuer bool) (*TOTP, error) {
+	if err := cfg.validate(needIssuer); err != nil {
 		return nil, err
 	}
 
-	hotp, err := NewHOTP(HOTPConfig{
-		Algo:   cfg.Algo,
-		Digits: cfg.Digits,
-		Issuer: cfg.Issuer,
-	})
+	hotp, err := newHOTP(HOTPConfig{
+		Algo:           cfg.Algo,
+		Digits:         cfg.Digits,
+		Issuer:         cfg.Issuer,
+		Encoder:        cfg.Encoder,
+		LongCodes:      cfg.LongCodes,
+		SecretEncoding: cfg.SecretEncoding,
+	}, needIssuer)
 	if err != nil {
 		return nil, err
 	}
-	return &TOTP{
+
+	step := cfg.Interval
+	if step == 0 {
+		step = time.Duration(cfg.Period) * time.Second
+	}
+	t := &TOTP{
 		hotp: *hotp,
 		cfg:  cfg,
-	}, nil
+		step: step,
+
+		skewBackward: uint64(cfg.SkewBackward),
+		skewForward:  uint64(cfg.SkewForward),
+	}
+	if cfg.Skew != 0 {
+		t.skewBackward = uint64(cfg.Skew)
+		t.skewForward = uint64(cfg.Skew)
+	}
+	return t, nil
+}
+
+// Config returns a copy of the TOTP config.
+func (t *TOTP) Config() TOTPConfig { return t.cfg }
+
+// Period returns the time step duration set by Period or Interval.
+func (t *TOTP) Period() time.Duration { return t.step }
+
+// NewSteamTOTP creates new TOTP producing Steam Guard codes:
+// SHA1, 30 seconds period and 5 characters from "23456789BCDFGHJKMNPQRTVWXY".
+func NewSteamTOTP(issuer string) (*TOTP, error) {
+	return newSteamTOTP(issuer, true)
+}
+
+func newSteamTOTP(issuer string, needIssuer bool) (*TOTP, error) {
+	return newTOTP(TOTPConfig{
+		Algo:    AlgorithmSHA1,
+		Digits:  5,
+		Issuer:  issuer,
+		Period:  30,
+		Skew:    1,
+		Encoder: EncoderSteam,
+	}, needIssuer)
+}
+
+// NewTOTPFromKey creates new TOTP from the TOTP key.
+// Unset algorithm, digits and period default to SHA1, 6 digits and 30 seconds.
+// Skew is set to 1.
+//
+// Steam keys (otpauth://steam or encoder=steam) create Steam Guard TOTP.
+// The key may have no issuer.
+func NewTOTPFromKey(key *Key) (*TOTP, error) {
+	if key.IsSteam() {
+		return newSteamTOTP(key.Issuer(), false)
+	}
+	if key.Type() != "totp" {
+		return nil, ErrTypeNotValid
+	}
+	algo, digits := key.algoDigits()
+	return newTOTP(TOTPConfig{
+		Algo:   algo,
+		Digits: digits,
+		Issuer: key.Issuer(),
+		Period: key.Period(),
+		Skew:   1,
+	}, false)
 }
 
 // GenerateURL for the account for a given secret.
+//
+// WARNING: the URL period is in whole seconds. When Interval is not
+// a whole number of seconds the period is omitted and apps fall back
+// to 30 seconds, producing wrong codes. The URL has no sign of LongCodes
+// either. Use GenerateURLStrict to get an error.
 func (t *TOTP) GenerateURL(account string, secret []byte) string {
-	v := url.Values{}
-	v.Set("algorithm", t.cfg.Algo.String())
-	v.Set("digits", atoi(uint64(t.cfg.Digits)))
-	v.Set("issuer", t.cfg.Issuer)
-	v.Set("secret", b32Enc(secret))
-	v.Set("period", atoi(t.cfg.Period))
+	return t.key(account, secret).String()
+}
 
-	u := url.URL{
-		Scheme:   "otpauth",
-		Host:     "totp",
-		Path:     "/" + t.cfg.Issuer + ":" + account,
-		RawQuery: v.Encode(),
+// GenerateURLStrict is like GenerateURL but returns ErrPeriodNotValid
+// when Interval is not a whole number of seconds and ErrLongCodesInURL
+// for LongCodes.
+func (t *TOTP) GenerateURLStrict(account string, secret []byte) (string, error) {
+	switch {
+	case t.step%time.Second != 0:
+		return "", ErrPeriodNotValid
+	case t.cfg.LongCodes:
+		return "", ErrLongCodesInURL
 	}
-	return u.String()
+	return t.key(account, secret).String(), nil
 }
 
-// GenerateCode for the given counter and secret.
+func (t *TOTP) key(account string, secret []byte) *Key {
+	key := NewKey("totp", t.cfg.Issuer, account).
+		SetAlgorithm(t.cfg.Algo).
+		SetDigits(t.cfg.Digits).
+		SetSecret(secret)
+	// Period is set only when it can be expressed in seconds.
+	if t.step%time.Second == 0 {
+		key.SetPeriod(uint64(t.step / time.Second))
+	}
+	if t.cfg.Encoder == EncoderSteam {
+		key.SetParam("encoder", "steam")
+	}
+	return key
+}
+
+// GenerateCode for the given time and secret.
+// Returns ErrTimeNotValid if the time is before T0.
 func (t *TOTP) GenerateCode(secret string, at time.Time) (string, error) {
-	counter := uint64(math.Floor(float64(at.Unix()) / float64(t.cfg.Period)))
+	counter, err := t.counter(at)
+	if err != nil {
+		return "", err
+	}
 	code, err := t.hotp.GenerateCode(counter, secret)
 	if err != nil {
 		return "", err
@@ -85,27 +225,211 @@ func (t *TOTP) GenerateCode(secret string, at time.Time) (string, error) {
 	return code, nil
 }
 
+// GenerateCodeSecret for the given time and decoded secret.
+// Returns ErrTimeNotValid if the time is before T0.
+func (t *TOTP) GenerateCodeSecret(secret *Secret, at time.Time) (string, error) {
+	counter, err := t.counter(at)
+	if err != nil {
+		return "", err
+	}
+	return t.hotp.GenerateCodeSecret(counter, secret), nil
+}
+
+// AppendCode appends the code for the given time and decoded secret to dst
+// and returns the extended buffer. It does not allocate when dst has capacity
+// for Digits more bytes. Returns ErrTimeNotValid if the time is before T0.
+func (t *TOTP) AppendCode(dst []byte, secret *Secret, at time.Time) ([]byte, error) {
+	counter, err := t.counter(at)
+	if err != nil {
+		return dst, err
+	}
+	return t.hotp.AppendCode(dst, counter, secret), nil
+}
+
+// Code returns the code for the current time of the clock.
+func (t *TOTP) Code(secret string) (string, error) {
+	return t.GenerateCode(secret, t.now())
+}
+
+// Verify the given passcode and secret at the current time of the clock.
+func (t *TOTP) Verify(passcode, secret string) error {
+	return t.Validate(passcode, t.now(), secret)
+}
+
+// Step returns the time step (counter) for the given time.
+// Returns ErrTimeNotValid if the time is before T0.
+func (t *TOTP) Step(at time.Time) (uint64, error) {
+	return t.counter(at)
+}
+
+// NextRollover returns the start time of the time step after the given time.
+func (t *TOTP) NextRollover(at time.Time) (time.Time, error) {
+	counter, err := t.counter(at)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.stepStart(counter + 1), nil
+}
+
+// Remaining returns the time left until the next time step.
+func (t *TOTP) Remaining(at time.Time) (time.Duration, error) {
+	next, err := t.NextRollover(at)
+	if err != nil {
+		return 0, err
+	}
+	return next.Sub(at), nil
+}
+
+// StepNow returns the time step for the current time of the clock.
+func (t *TOTP) StepNow() (uint64, error) {
+	return t.Step(t.now())
+}
+
+// NextRolloverNow returns the start of the next time step for the current time of the clock.
+func (t *TOTP) NextRolloverNow() (time.Time, error) {
+	return t.NextRollover(t.now())
+}
+
+// RemainingNow returns the time left until the next time step for the current time of the clock.
+func (t *TOTP) RemainingNow() (time.Duration, error) {
+	return t.Remaining(t.now())
+}
+
+func (t *TOTP) now() time.Time {
+	if t.cfg.Clock == nil {
+		return time.Now()
+	}
+	return t.cfg.Clock.Now()
+}
+
+// TOTPResult represents the time step matched during TOTP validation.
+type TOTPResult struct {
+	// Counter is the matched time step.
+	Counter uint64
+	// Offset is the signed offset from the current time step.
+	Offset int
+	// Start is the start time of the matched time step.
+	Start time.Time
+	// End is the end time (exclusive) of the matched time step.
+	End time.Time
+}
+
 // Validate the given passcode, time and secret.
 func (t *TOTP) Validate(passcode string, at time.Time, secret string) error {
+	_, err := t.ValidateResult(passcode, at, secret)
+	return err
+}
+
+// ValidateResult validates the given passcode, time and secret
+// and returns the matched time step.
+//
+// The current time step is checked first, then the nearest steps outward
+// within the skew window, a past step before a future one.
+func (t *TOTP) ValidateResult(passcode string, at time.Time, secret string) (TOTPResult, error) {
 	if len(passcode) != int(t.cfg.Digits) {
-		return ErrCodeLengthMismatch
+		return TOTPResult{}, ErrCodeLengthMismatch
 	}
 
-	counter := int64(math.Floor(float64(at.Unix()) / float64(t.cfg.Period)))
+	st, err := t.hotp.state(secret)
+	if err != nil {
+		return TOTPResult{}, err
+	}
+	return t.validateResult(passcode, at, st)
+}
 
-	if err := t.hotp.Validate(passcode, uint64(counter), secret); err == nil {
-		return nil
+// ValidateSecret validates the given passcode, time and decoded secret.
+func (t *TOTP) ValidateSecret(passcode string, at time.Time, secret *Secret) error {
+	_, err := t.ValidateResultSecret(passcode, at, secret)
+	return err
+}
+
+// ValidateResultSecret is like ValidateResult but for the decoded secret.
+func (t *TOTP) ValidateResultSecret(passcode string, at time.Time, secret *Secret) (TOTPResult, error) {
+	if len(passcode) != int(t.cfg.Digits) {
+		return TOTPResult{}, ErrCodeLengthMismatch
 	}
 
-	for i := uint(1); i <= t.cfg.Skew; i++ {
-		if err := t.hotp.Validate(passcode, uint64(counter+int64(i)), secret); err == nil {
-			return nil
+	st := secret.state(t.cfg.Algo)
+	defer secret.release(t.cfg.Algo, st)
+	return t.validateResult(passcode, at, st)
+}
+
+func (t *TOTP) validateResult(passcode string, at time.Time, st *macState) (TOTPResult, error) {
+	counter, err := t.counter(at)
+	if err != nil {
+		return TOTPResult{}, err
+	}
+
+	if t.hotp.match(st, passcode, counter) {
+		return t.result(counter, 0), nil
+	}
+
+	for i := uint64(1); i <= t.skewBackward || i <= t.skewForward; i++ {
+		if i <= t.skewBackward && counter >= i && t.hotp.match(st, passcode, counter-i) {
+			return t.result(counter-i, -int(i)), nil
 		}
 
-		if err := t.hotp.Validate(passcode, uint64(counter-int64(i)), secret); err == nil {
-			return nil
+		if i <= t.skewForward && counter+i > counter && t.hotp.match(st, passcode, counter+i) {
+			return t.result(counter+i, int(i)), nil
 		}
 	}
 
-	return ErrCodeIsNotValid
+	return TOTPResult{}, ErrCodeIsNotValid
+}
+
+func (t *TOTP) result(counter uint64, offset int) TOTPResult {
+	return TOTPResult{
+		Counter: counter,
+		Offset:  offset,
+		Start:   t.stepStart(counter),
+		End:     t.stepStart(counter + 1),
+	}
+}
+
+func (t *TOTP) t0() time.Time {
+	if t.cfg.T0.IsZero() {
+		return time.Unix(0, 0)
+	}
+	return t.cfg.T0
+}
+
+// counter returns the number of time steps between T0 and the given time.
+// See: https://datatracker.ietf.org/doc/html/rfc6238#section-4.2
+func (t *TOTP) counter(at time.Time) (uint64, error) {
+	t0 := t.t0()
+	if at.Before(t0) {
+		return 0, ErrTimeNotValid
+	}
+
+	sec := at.Unix() - t0.Unix()
+	nsec := int64(at.Nanosecond()) - int64(t0.Nanosecond())
+	if nsec < 0 {
+		sec--
+		nsec += int64(time.Second)
+	}
+
+	if t.step%time.Second == 0 {
+		return uint64(sec) / uint64(t.step/time.Second), nil
+	}
+
+	// Nanoseconds since T0 do not fit into int64 after ~292 years, use 128 bits.
+	hi, lo := bits.Mul64(uint64(sec), uint64(time.Second))
+	lo, carry := bits.Add64(lo, uint64(nsec), 0)
+	hi += carry
+	if hi >= uint64(t.step) {
+		return 0, ErrTimeNotValid
+	}
+	counter, _ := bits.Div64(hi, lo, uint64(t.step))
+	return counter, nil
+}
+
+// stepStart returns the start time of the given time step.
+func (t *TOTP) stepStart(counter uint64) time.Time {
+	t0 := t.t0()
+	hi, lo := bits.Mul64(counter, uint64(t.step))
+	if hi >= uint64(time.Second) {
+		return time.Unix(math.MaxInt64, 0)
+	}
+	sec, nsec := bits.Div64(hi, lo, uint64(time.Second))
+	return time.Unix(t0.Unix()+int64(sec), int64(t0.Nanosecond())+int64(nsec))
 }
@@ -53,6 +53,192 @@ func TestTOTP(t *testing.T) {
 	}
 }
 
+func TestTOTPValidateResult(t *testing.T) {
+	totp, err := NewTOTP(TOTPConfig{
+		Algo:   AlgorithmSHA1,
+		Digits: 8,
+		Issuer: "cristalhq",
+		Period: 30,
+		Skew:   2,
+	})
+	mustOk(t, err)
+
+	at := time.Unix(1111111111, 0)
+
+	testCases := []struct {
+		at     time.Time
+		offset int
+	}{
+		{at, 0},
+		{at.Add(-30 * time.Second), 1},
+		{at.Add(-60 * time.Second), 2},
+		{at.Add(30 * time.Second), -1},
+		{at.Add(60 * time.Second), -2},
+	}
+
+	for _, tc := range testCases {
+		res, err := totp.ValidateResult("14050471", tc.at, secretSha1)
+		mustOk(t, err)
+		mustEqual(t, res.Counter, uint64(37037037))
+		mustEqual(t, res.Offset, tc.offset)
+		mustEqual(t, res.Start.Unix(), int64(1111111110))
+		mustEqual(t, res.End.Unix(), int64(1111111140))
+	}
+
+	_, err = totp.ValidateResult("14050471", at.Add(90*time.Second), secretSha1)
+	mustEqual(t, err, ErrCodeIsNotValid)
+
+	_, err = totp.ValidateResult("1405047", at, secretSha1)
+	mustEqual(t, err, ErrCodeLengthMismatch)
+}
+
+func TestTOTPSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+
+	testCases := []struct {
+		backward, forward uint
+		offsets           []int
+	}{
+		{0, 0, []int{0}},
+		{1, 0, []int{0, -1}},
+		{0, 2, []int{0, 1, 2}},
+		{2, 1, []int{0, -1, -2, 1}},
+	}
+
+	for _, tc := range testCases {
+		totp, err := NewTOTP(TOTPConfig{
+			Algo:         AlgorithmSHA1,
+			Digits:       8,
+			Issuer:       "cristalhq",
+			Period:       30,
+			SkewBackward: tc.backward,
+			SkewForward:  tc.forward,
+		})
+		mustOk(t, err)
+
+		accepted := map[int]bool{}
+		for _, offset := range tc.offsets {
+			accepted[offset] = true
+		}
+
+		for offset := -3; offset <= 3; offset++ {
+			// The code is generated at the matched step, validation is done now.
+			code, err := totp.GenerateCode(secretSha1, at.Add(time.Duration(offset)*30*time.Second))
+			mustOk(t, err)
+
+			res, err := totp.ValidateResult(code, at, secretSha1)
+			if !accepted[offset] {
+				mustEqual(t, err, ErrCodeIsNotValid)
+				continue
+			}
+			mustOk(t, err)
+			mustEqual(t, res.Offset, offset)
+		}
+	}
+}
+
+func TestTOTPEpoch(t *testing.T) {
+	newTOTP := func(t0 time.Time, interval time.Duration) *TOTP {
+		cfg := TOTPConfig{
+			Algo:   AlgorithmSHA1,
+			Digits: 8,
+			Issuer: "cristalhq",
+			Skew:   1,
+			T0:     t0,
+		}
+		if interval == 0 {
+			cfg.Period = 30
+		} else {
+			cfg.Interval = interval
+		}
+		totp, err := NewTOTP(cfg)
+		mustOk(t, err)
+		return totp
+	}
+
+	testCases := []struct {
+		t0       time.Time
+		interval time.Duration
+		at       time.Time
+	}{
+		{time.Time{}, 0, time.Unix(59, 0)},
+		{time.Unix(1000, 0), 0, time.Unix(1059, 0)},
+		{time.Unix(-1000, 0), 0, time.Unix(-941, 0)},
+		{time.Unix(0, 0), 15 * time.Second, time.Unix(29, 999)},
+		{time.Unix(0, 0), 500 * time.Millisecond, time.Unix(0, 999999999)},
+		{time.Unix(10, 250), 250 * time.Millisecond, time.Unix(10, 250000250)},
+	}
+
+	for _, tc := range testCases {
+		totp := newTOTP(tc.t0, tc.interval)
+		code, err := totp.GenerateCode(secretSha1, tc.at)
+		mustOk(t, err)
+		mustEqual(t, code, "94287082")
+
+		res, err := totp.ValidateResult(code, tc.at, secretSha1)
+		mustOk(t, err)
+		mustEqual(t, res.Counter, uint64(1))
+		mustEqual(t, res.End.Sub(res.Start), totp.step)
+		mustEqual(t, res.Start.Equal(totp.t0().Add(totp.step)), true)
+	}
+
+	totp := newTOTP(time.Unix(1000, 0), 0)
+	_, err := totp.GenerateCode(secretSha1, time.Unix(999, 0))
+	mustEqual(t, err, ErrTimeNotValid)
+
+	err = totp.Validate("94287082", time.Unix(1000, -1), secretSha1)
+	mustEqual(t, err, ErrTimeNotValid)
+
+	_, err = newTOTP(time.Time{}, 0).GenerateCode(secretSha1, time.Unix(-1, 0))
+	mustEqual(t, err, ErrTimeNotValid)
+
+	// Far future time steps are computed exactly.
+	at := time.Unix(1<<62, 0)
+	totp = newTOTP(time.Time{}, 0)
+	code, err := totp.GenerateCode(secretSha1, at)
+	mustOk(t, err)
+	want, err := totp.hotp.GenerateCode((1<<62)/30, secretSha1)
+	mustOk(t, err)
+	mustEqual(t, code, want)
+
+	_, err = newTOTP(time.Time{}, time.Millisecond).GenerateCode(secretSha1, at)
+	mustEqual(t, err, ErrTimeNotValid)
+}
+
+func TestSteamTOTP(t *testing.T) {
+	totp, err := NewSteamTOTP("Steam")
+	mustOk(t, err)
+
+	testCases := []struct {
+		ts   int64
+		code string
+	}{
+		{0, "GG5F5"},
+		{59, "PV9M4"},
+		{60, "B26KJ"},
+		{119, "5H85C"},
+	}
+
+	for _, tc := range testCases {
+		at := time.Unix(tc.ts, 0)
+		code, err := totp.GenerateCode(secretSha1, at)
+		mustOk(t, err)
+		mustEqual(t, code, tc.code)
+
+		err = totp.Validate(tc.code, at, secretSha1)
+		mustOk(t, err)
+	}
+
+	err = totp.Validate("GG5F4", time.Unix(0, 0), secretSha1)
+	mustEqual(t, err, ErrCodeIsNotValid)
+
+	err = totp.Validate("755224", time.Unix(0, 0), secretSha1)
+	mustEqual(t, err, ErrCodeLengthMismatch)
+
+	url := totp.GenerateURL("alice", []byte("SECRET_STRING"))
+	mustEqual(t, url, "otpauth://totp/Steam:alice?algorithm=SHA1&digits=5&encoder=steam&issuer=Steam&period=30&secret=KNCUGUSFKRPVGVCSJFHEO")
+}
+
 func TestNewTOTP(t *testing.T) {
 	_, err := NewTOTP(TOTPConfig{
 		Algo:   0,
@@ -99,6 +285,25 @@ func TestNewTOTP(t *testing.T) {
 	})
 	mustEqual(t, err, ErrPeriodNotValid)
 
+	_, err = NewTOTP(TOTPConfig{
+		Algo:     1,
+		Digits:   8,
+		Issuer:   "cristalhq",
+		Period:   30,
+		Interval: 30 * time.Second,
+		Skew:     1,
+	})
+	mustEqual(t, err, ErrPeriodNotValid)
+
+	_, err = NewTOTP(TOTPConfig{
+		Algo:     1,
+		Digits:   8,
+		Issuer:   "cristalhq",
+		Interval: -time.Second,
+		Skew:     1,
+	})
+	mustEqual(t, err, ErrPeriodNotValid)
+
 	_, err = NewTOTP(TOTPConfig{
 		Algo:   1,
 		Digits: 8,
@@ -106,9 +311,71 @@ func TestNewTOTP(t *testing.T) {
 		Period: 30,
 		Skew:   0,
 	})
+	mustOk(t, err)
+
+	_, err = NewTOTP(TOTPConfig{
+		Algo:        1,
+		Digits:      8,
+		Issuer:      "cristalhq",
+		Period:      30,
+		Skew:        1,
+		SkewForward: 1,
+	})
 	mustEqual(t, err, ErrSkewNotValid)
 }
 
+func TestTOTPStep(t *testing.T) {
+	cfg := TOTPConfig{
+		Algo:   AlgorithmSHA256,
+		Digits: 8,
+		Issuer: "cristalhq",
+		Period: 60,
+		Skew:   1,
+		T0:     time.Unix(1000, 0),
+	}
+	totp, err := NewTOTP(cfg)
+	mustOk(t, err)
+
+	mustEqual(t, totp.Config(), cfg)
+	mustEqual(t, totp.Period(), time.Minute)
+
+	at := time.Unix(1130, 500)
+	step, err := totp.Step(at)
+	mustOk(t, err)
+	mustEqual(t, step, uint64(2))
+
+	next, err := totp.NextRollover(at)
+	mustOk(t, err)
+	mustEqual(t, next, time.Unix(1180, 0))
+
+	remaining, err := totp.Remaining(at)
+	mustOk(t, err)
+	mustEqual(t, remaining, 50*time.Second-500)
+
+	remaining, err = totp.Remaining(time.Unix(1120, 0))
+	mustOk(t, err)
+	mustEqual(t, remaining, time.Minute)
+
+	_, err = totp.Step(time.Unix(999, 0))
+	mustEqual(t, err, ErrTimeNotValid)
+
+	_, err = totp.Remaining(time.Unix(999, 0))
+	mustEqual(t, err, ErrTimeNotValid)
+
+	totp, err = NewTOTP(TOTPConfig{
+		Algo:     AlgorithmSHA1,
+		Digits:   6,
+		Issuer:   "cristalhq",
+		Interval: 1500 * time.Millisecond,
+	})
+	mustOk(t, err)
+	mustEqual(t, totp.Period(), 1500*time.Millisecond)
+
+	remaining, err = totp.Remaining(time.Unix(1, 0))
+	mustOk(t, err)
+	mustEqual(t, remaining, 500*time.Millisecond)
+}
+
 func TestTOTPGenerateURL(t *testing.T) {
 	totp, err := NewTOTP(TOTPConfig{
 		Algo:   AlgorithmSHA1,
@@ -124,6 +391,36 @@ func TestTOTPGenerateURL(t *testing.T) {
 
 	url = totp.GenerateURL("bob@alice.com", []byte("SECRET_STRING"))
 	mustEqual(t, url, "otpauth://totp/cristalhq:bob@alice.com?algorithm=SHA1&digits=8&issuer=cristalhq&period=30&secret=KNCUGUSFKRPVGVCSJFHEO")
+
+	strict, err := totp.GenerateURLStrict("bob@alice.com", []byte("SECRET_STRING"))
+	mustOk(t, err)
+	mustEqual(t, strict, url)
+
+	totp, err = NewTOTP(TOTPConfig{
+		Algo:     AlgorithmSHA1,
+		Digits:   8,
+		Issuer:   "cristalhq",
+		Interval: 1500 * time.Millisecond,
+	})
+	mustOk(t, err)
+
+	url = totp.GenerateURL("alice@bob.com", []byte("SECRET_STRING"))
+	mustEqual(t, url, "otpauth://totp/cristalhq:alice@bob.com?algorithm=SHA1&digits=8&issuer=cristalhq&secret=KNCUGUSFKRPVGVCSJFHEO")
+
+	_, err = totp.GenerateURLStrict("alice@bob.com", []byte("SECRET_STRING"))
+	mustEqual(t, err, ErrPeriodNotValid)
+
+	totp, err = NewTOTP(TOTPConfig{
+		Algo:      AlgorithmSHA1,
+		Digits:    10,
+		Issuer:    "cristalhq",
+		Period:    30,
+		LongCodes: true,
+	})
+	mustOk(t, err)
+
+	_, err = totp.GenerateURLStrict("alice@bob.com", []byte("SECRET_STRING"))
+	mustEqual(t, err, ErrLongCodesInURL)
 }
 
 func BenchmarkTOTP_GenerateURL(b *testing.B) {
@@ -195,3 +492,59 @@ func BenchmarkTOTP_Validate(b *testing.B) {
 		mustOk(b, err)
 	}
 }
+
+func BenchmarkTOTP_AppendCode(b *testing.B) {
+	for _, bc := range benchAlgos {
+		b.Run(bc.algo.String(), func(b *testing.B) {
+			totp, err := NewTOTP(TOTPConfig{
+				Algo:   bc.algo,
+				Digits: 8,
+				Issuer: "cristalhq",
+				Period: 30,
+			})
+			mustOk(b, err)
+
+			secret, err := NewSecretFromBase32(bc.secret)
+			mustOk(b, err)
+			at := time.Now()
+			buf := make([]byte, 0, 8)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				buf, err = totp.AppendCode(buf[:0], secret, at)
+				mustOk(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkTOTP_ValidateSecret(b *testing.B) {
+	for _, bc := range benchAlgos {
+		b.Run(bc.algo.String(), func(b *testing.B) {
+			totp, err := NewTOTP(TOTPConfig{
+				Algo:   bc.algo,
+				Digits: 8,
+				Issuer: "cristalhq",
+				Period: 30,
+				Skew:   1,
+			})
+			mustOk(b, err)
+
+			secret, err := NewSecretFromBase32(bc.secret)
+			mustOk(b, err)
+			at := time.Now()
+			passcode, err := totp.GenerateCodeSecret(secret, at)
+			mustOk(b, err)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				err := totp.ValidateSecret(passcode, at, secret)
+				mustOk(b, err)
+			}
+		})
+	}
+}